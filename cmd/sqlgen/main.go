@@ -5,12 +5,14 @@
 package main
 
 import (
+	"errors"
 	"flag"
 	"fmt"
 	"go/ast"
 	"go/build"
 	"go/importer"
 	"go/types"
+	"io/ioutil"
 	"os"
 	"path/filepath"
 	"strings"
@@ -93,6 +95,10 @@ func parseFlags() {
 		flag.Usage()
 		os.Exit(255)
 	}
+	if *flSkipSource && *flFile == "" {
+		fmt.Fprint(os.Stderr, "Flag -s requires a definition file (-f)\n")
+		os.Exit(1)
+	}
 }
 
 type Decl struct {
@@ -104,62 +110,30 @@ func parsePackage(pkgpath string) error {
 	decl, err := gocmt.ParseDir(pkgpath)
 	must(err)
 
-	var b strings.Builder
-	for _, group := range decl.Block {
-		for _, line := range group {
-			b.WriteString(line)
-			b.WriteString("\n")
-		}
-	}
-	src := b.String()
-	fileDecl, err := dsl.ParseString("unknown", src)
-	if err != nil {
-		return err
-	}
-
-	for _, decl := range fileDecl.Declarations {
-		if err = linkDeclaration(decl, nil); err != nil {
+	var decls []*dsl.Declaration
+	if !*flSkipSource {
+		srcDecls, err := parseSourceDeclarations(decl)
+		if err != nil {
 			return err
 		}
+		decls = append(decls, srcDecls...)
 	}
-
-	typeDecls := make([]*dsl.Declaration, len(decl.Types))
-	for i, t := range decl.Types {
-		b.Reset()
-		for _, line := range t.Comment {
-			b.WriteString(line)
-		}
-		s := b.String()
-		name := t.Type.Name.Name
-		typeDecl, err := dsl.ParseString("type "+name, s)
+	if *flFile != "" {
+		fileDecls, err := parseDefinitionFile(pkgpath, *flFile)
 		if err != nil {
-			return fmt.Errorf("Parse error on type %v: %v", name, err)
-		}
-
-		switch len(typeDecl.Declarations) {
-		case 0:
-			return fmt.Errorf("Empty declarations on type %v", name)
-		case 1:
-			typeDecls[i] = typeDecl.Declarations[0]
-			if err = linkDeclaration(typeDecls[i], t.Type); err != nil {
-				return err
-			}
-		default:
-			return fmt.Errorf("Multiple declarations on type %v", name)
+			return err
 		}
+		decls = append(decls, fileDecls...)
 	}
 
 	if *flPrint {
-		fmt.Println(fileDecl)
-		for _, decl := range typeDecls {
-			fmt.Println(decl)
-		}
+		fmt.Print(dsl.Declarations(decls))
 		return nil
 	}
 
 	var allDecls []*Decl
 	mapDecl := make(map[string]*Decl)
-	for _, decl := range fileDecl.Declarations {
+	for _, decl := range decls {
 		d, err := addToMap(mapDecl, decl)
 		if err != nil {
 			return err
@@ -167,36 +141,29 @@ func parsePackage(pkgpath string) error {
 		allDecls = append(allDecls, d)
 	}
 
-	for _, decl := range typeDecls {
-		d, err := addToMap(mapDecl, decl)
-		if err != nil {
-			return err
-		}
-		allDecls = append(allDecls, d)
-	}
-
-	for _, decl := range fileDecl.Declarations {
+	for _, decl := range decls {
 		for _, jn := range decl.Joins {
 			if jn.TableName == "" {
-				return fmt.Errorf("Empty table name for join")
+				return declError(decl, "Empty table name for join")
 			}
 			if jn.StructName == "" {
-				for name, d := range mapDecl {
+				// The first declaration of the table wins
+				for _, d := range allDecls {
 					if d.Decl.TableName == jn.TableName {
-						jn.StructName = name
+						jn.StructName = d.Decl.StructName
 						break
 					}
 				}
 			}
 			if jn.StructName == "" {
-				return fmt.Errorf("Struct name not found for join with table %v", jn.TableName)
+				return declError(decl, "Struct name not found for join with table %v", jn.TableName)
 			}
 			d, ok := mapDecl[jn.StructName]
 			if !ok {
-				return fmt.Errorf("Struct %v using in join must be declared first", jn.StructName)
+				return declError(decl, "Struct %v using in join must be declared first", jn.StructName)
 			}
 			if d.Decl.TableName != jn.TableName {
-				return fmt.Errorf("Table name %v not match for struct %v", jn.TableName, jn.StructName)
+				return declError(decl, "Table name %v not match for struct %v", jn.TableName, jn.StructName)
 			}
 		}
 	}
@@ -222,14 +189,18 @@ func parsePackage(pkgpath string) error {
 	}
 
 	for ident, d := range info.Defs {
+		// Only package level types, not fields or variables of the same name
+		if _, ok := d.(*types.TypeName); !ok || d.Parent() != tpkg.Scope() {
+			continue
+		}
 		decl := mapDecl[ident.Name]
 		if decl != nil {
 			decl.Type = d.Type()
 		}
 	}
-	for name, decl := range mapDecl {
-		if decl.Type == nil {
-			return fmt.Errorf("Error: type %v not found", name)
+	for _, d := range allDecls {
+		if d.Type == nil {
+			return declError(d.Decl, "Error: type %v not found", d.Decl.StructName)
 		}
 	}
 
@@ -247,14 +218,14 @@ func parsePackage(pkgpath string) error {
 	for _, decl := range allDecls {
 		err = g.Add(getTypeForStruct, decl.Decl.StructName, decl.Type, decl.Decl)
 		if err != nil {
-			return err
+			return declError(decl.Decl, "%v", err)
 		}
 	}
 	g.GenerateCommon()
 	for _, decl := range allDecls {
 		err = g.GenQueryFor(decl.Type)
 		if err != nil {
-			return err
+			return declError(decl.Decl, "%v", err)
 		}
 	}
 
@@ -271,13 +242,102 @@ func parsePackage(pkgpath string) error {
 	return nil
 }
 
+// parseSourceDeclarations parses declarations from "sqlgen:" comments in the
+// package source.
+func parseSourceDeclarations(decl *gocmt.PackageDeclaration) ([]*dsl.Declaration, error) {
+	var b strings.Builder
+	for _, group := range decl.Block {
+		for _, line := range group {
+			b.WriteString(line)
+			b.WriteString("\n")
+		}
+	}
+	src := b.String()
+	fileDecl, err := dsl.ParseString("unknown", src)
+	if err != nil {
+		return nil, err
+	}
+
+	decls := fileDecl.Declarations
+	for _, decl := range decls {
+		if err = linkDeclaration(decl, nil); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, t := range decl.Types {
+		b.Reset()
+		for _, line := range t.Comment {
+			b.WriteString(line)
+		}
+		s := b.String()
+		name := t.Type.Name.Name
+		typeDecl, err := dsl.ParseString("type "+name, s)
+		if err != nil {
+			return nil, fmt.Errorf("Parse error on type %v: %v", name, err)
+		}
+
+		switch len(typeDecl.Declarations) {
+		case 0:
+			return nil, fmt.Errorf("Empty declarations on type %v", name)
+		case 1:
+			d := typeDecl.Declarations[0]
+			if err = linkDeclaration(d, t.Type); err != nil {
+				return nil, err
+			}
+			decls = append(decls, d)
+		default:
+			return nil, fmt.Errorf("Multiple declarations on type %v", name)
+		}
+	}
+	return decls, nil
+}
+
+// parseDefinitionFile parses declarations from a definition file. A relative
+// path is resolved from the package directory.
+func parseDefinitionFile(pkgpath, filename string) ([]*dsl.Declaration, error) {
+	if !filepath.IsAbs(filename) {
+		filename = filepath.Join(pkgpath, filename)
+	}
+	data, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+
+	// Report positions relative to the working directory when possible
+	name := filename
+	if wdir, err := os.Getwd(); err == nil {
+		if rel, err := filepath.Rel(wdir, filename); err == nil && !strings.HasPrefix(rel, "..") {
+			name = rel
+		}
+	}
+	fileDecl, err := dsl.ParseString(name, string(data))
+	if err != nil {
+		return nil, err
+	}
+	for _, decl := range fileDecl.Declarations {
+		if err = linkDeclaration(decl, nil); err != nil {
+			return nil, err
+		}
+	}
+	return fileDecl.Declarations, nil
+}
+
+func declError(decl *dsl.Declaration, format string, args ...interface{}) error {
+	msg := fmt.Sprintf(format, args...)
+	if decl.Pos.IsValid() {
+		return fmt.Errorf("%v: %v", decl.Pos, msg)
+	}
+	return errors.New(msg)
+}
+
 func linkDeclaration(decl *dsl.Declaration, typ *ast.TypeSpec) error {
 	if err := decl.ParseOptions(); err != nil {
-		return fmt.Errorf("Error: %v on declaration:\n\n%v", err, decl)
+		return declError(decl, "Error: %v on declaration:\n\n%v", err, decl)
 	}
 	if decl.StructName == "" {
 		if typ == nil {
-			return fmt.Errorf("Error: no struct name on declaration:\n\n%v", decl)
+			return declError(decl, "Error: no struct name on declaration:\n\n%v", decl)
 		}
 		decl.StructName = typ.Name.Name
 	}
@@ -294,8 +354,11 @@ func addToMap(m map[string]*Decl, decl *dsl.Declaration) (*Decl, error) {
 	if decl.StructName == "" {
 		return nil, fmt.Errorf("No struct name on declaration\n\n%v", decl)
 	}
-	if _, ok := m[decl.StructName]; ok {
-		return nil, fmt.Errorf("Duplicated declaration for type %v", decl.StructName)
+	if prev, ok := m[decl.StructName]; ok {
+		if prev.Decl.Pos.IsValid() {
+			return nil, declError(decl, "Duplicated declaration for type %v (previous declaration at %v)", decl.StructName, prev.Decl.Pos)
+		}
+		return nil, declError(decl, "Duplicated declaration for type %v", decl.StructName)
 	}
 	d := &Decl{
 		Decl: decl,
@@ -29,7 +29,9 @@ func (r Row) Scan(dest ...interface{}) error {
 type CommonQuery interface {
 	Get(obj IGet, preds ...interface{}) (bool, error)
 	Find(objs IFind, preds ...interface{}) error
+	Iterate(ctx context.Context, obj IIterate, fn func() error) error
 	Insert(objs ...IInsert) (int64, error)
+	Upsert(objs ...IUpsert) (int64, error)
 	Update(objs ...IUpdate) (int64, error)
 	UpdateMap(m map[string]interface{}) (int64, error)
 	Delete(obj ITableName) (int64, error)
@@ -45,8 +47,17 @@ type CommonQuery interface {
 	GroupBy(groupBys ...string) Query
 	Limit(limit uint64) Query
 	Offset(offset uint64) Query
+	After(cursor string) Query
+	Before(cursor string) Query
 	Suffix(sql string, args ...interface{}) Query
 	UpdateAll() Query
+	UpdateValues() Query
+	OnConflict(cols ...string) Query
+	DoNothing() Query
+	DoUpdate(cols ...string) Query
+	SkipCreated() Query
+	Returning() Query
+	UsePrimary() Query
 	In(column string, args ...interface{}) Query
 	NotIn(column string, args ...interface{}) Query
 	Exists(column string, exists bool) Query
@@ -74,10 +85,13 @@ type Query interface {
 	BuildGet(obj IGet, preds ...interface{}) (string, []interface{}, error)
 	BuildFind(objs IFind, preds ...interface{}) (string, []interface{}, error)
 	BuildInsert(obj IInsert) (string, []interface{}, error)
+	BuildUpsert(obj IUpsert) (string, []interface{}, error)
 	BuildUpdate(obj IUpdate) (string, []interface{}, error)
+	BuildUpdateValues(objs []IUpdateValues) (string, []interface{}, error)
 	BuildDelete(obj ITableName) (string, []interface{}, error)
 	BuildCount(obj ITableName, preds ...interface{}) (string, []interface{}, error)
 	Clone() Query
+	Cursor(obj IColumnValue) (string, error)
 	Exec() (sql.Result, error)
 	Query() (*sql.Rows, error)
 	QueryRow() (Row, error)
@@ -112,6 +126,9 @@ var (
 
 type Opts struct {
 	UseArrayInsteadOfJSON bool
+
+	// Dialect of the database, see Dialect.
+	Dialect Dialect
 }
 
 func (opts Opts) Array(v interface{}) Array {
@@ -130,6 +147,11 @@ type ITableName interface {
 	SQLTableName() string
 }
 
+// ISchemaName is implemented by types declared with a schema.
+type ISchemaName interface {
+	SQLSchemaName() string
+}
+
 // IScan ...
 type IScan interface {
 	ITableName
@@ -154,6 +176,36 @@ type IInsert interface {
 	SQLInsert(SQLWriter) error
 }
 
+// IColumns ...
+type IColumns interface {
+	SQLColumns() []string
+}
+
+// IScanArgs ...
+type IScanArgs interface {
+	SQLScanArgs(opts Opts) []interface{}
+}
+
+// IColumnValue is implemented by generated select and join types for building
+// the cursors of keyset pagination. Columns of join types are qualified by
+// their alias, e.g. "u.id".
+type IColumnValue interface {
+	SQLColumnValue(opts Opts, col string) (interface{}, bool)
+}
+
+// ISetLastInsertID is implemented by types with an integer primary key, which
+// can be generated by the database.
+type ISetLastInsertID interface {
+	SQLSetLastInsertID(id int64)
+}
+
+// IUpsert ...
+type IUpsert interface {
+	IInsert
+	SQLUpsert(w SQLWriter, conflictCols []string, updateCols []string) error
+	SQLUpsertColumns(skipCreated bool) []string
+}
+
 // IUpdate ...
 type IUpdate interface {
 	ITableName
@@ -161,6 +213,15 @@ type IUpdate interface {
 	SQLUpdateAll(SQLWriter) error
 }
 
+// IUpdateValues is implemented by types which can be updated together with a
+// single UPDATE ... FROM (VALUES ...) statement.
+type IUpdateValues interface {
+	IUpdate
+	SQLColumns() []string
+	SQLPrimaryKeyColumns() []string
+	SQLArgs(opts Opts, create bool) []interface{}
+}
+
 // IGet ...
 type IGet interface {
 	ITableName
@@ -175,6 +236,18 @@ type IFind interface {
 	SQLScan(Opts, *sql.Rows) error
 }
 
+// IIterate ...
+type IIterate interface {
+	ISelect
+	IScanArgs
+}
+
+// IPrimaryKey ...
+type IPrimaryKey interface {
+	ITableName
+	SQLPrimaryKey(SQLWriter) error
+}
+
 // IJoin  ...
 type IJoin interface {
 	ITableName
@@ -190,6 +263,23 @@ type PreloadDesc struct {
 	Fkey  string
 	IDs   interface{}
 	Items IFind
+
+	// Composite foreign key. If set, IDs is a flatten list of tuples and Fkey
+	// is ignored.
+	Fkeys []string
+
+	// Many-to-many relation through the link table Through, which is prefixed
+	// with the schema of the parent if any. Fkey is the column of the link
+	// table referencing the parent and Ref is the column referencing RefKey of
+	// the items. Link returns the scan arguments for each row of the link
+	// table, RefIDs returns the scanned references and Populate is called
+	// instead of SQLPopulate.
+	Through  string
+	Ref      string
+	RefKey   string
+	Link     func() []interface{}
+	RefIDs   func() []interface{}
+	Populate func() error
 }
 
 type SQLWriter interface {
@@ -202,6 +292,7 @@ type SQLWriter interface {
 	WriteMarker()
 	WriteMarkers(n int)
 	WriteName(name string)
+	WriteOnConflict(conflictCols []string, updateCols []string)
 	WritePrefixedName(schema string, name string)
 	WriteQuery(b []byte)
 	WriteQueryName(name string)
@@ -211,6 +302,7 @@ type SQLWriter interface {
 	WriteRawString(s string)
 	WriteScanArg(arg interface{})
 	WriteScanArgs(args []interface{})
+	WriteSetColumns(cols []string)
 }
 
 type Interface struct{ V interface{} }
@@ -589,16 +681,50 @@ func (b Bool) Value() (driver.Value, error) {
 	return bool(b), nil
 }
 
-// Time ...
+// Time handles null as zero time and stores zero time as null. Besides
+// time.Time, it accepts ISO-8601 text and unix timestamps, which SQLite returns
+// for columns not declared as DATE, DATETIME or TIMESTAMP. Other text, like the
+// zero date "0000-00-00 00:00:00" of MySQL, is scanned as zero time.
 type Time time.Time
 
 // Scan implements the Scanner interface.
 func (t *Time) Scan(src interface{}) error {
-	tt, _ := src.(time.Time)
-	*t = Time(tt)
+	switch src := src.(type) {
+	case time.Time:
+		*t = Time(src)
+	case int64:
+		*t = Time(time.Unix(src, 0).UTC())
+	case string:
+		*t = scanTimeText(src)
+	case []byte:
+		*t = scanTimeText(string(src))
+	default:
+		*t = Time{}
+	}
 	return nil
 }
 
+// timeLayouts are the ISO-8601 layouts of SQLite. Times without time zone are
+// in UTC.
+var timeLayouts = []string{
+	"2006-01-02 15:04:05.999999999Z07:00",
+	"2006-01-02T15:04:05.999999999Z07:00",
+	"2006-01-02 15:04:05.999999999",
+	"2006-01-02T15:04:05.999999999",
+	"2006-01-02 15:04",
+	"2006-01-02T15:04",
+	"2006-01-02",
+}
+
+func scanTimeText(s string) Time {
+	for _, layout := range timeLayouts {
+		if tt, err := time.Parse(layout, s); err == nil {
+			return Time(tt)
+		}
+	}
+	return Time{}
+}
+
 // Value implements the driver Valuer interface.
 func (t Time) Value() (driver.Value, error) {
 	tt := time.Time(t)
@@ -653,7 +779,9 @@ func ArrayScanner(v interface{}) Array {
 	return Array{V: v, Opts: Opts{UseArrayInsteadOfJSON: true}}
 }
 
-// Array ...
+// Array encodes slices as native arrays when UseArrayInsteadOfJSON is set,
+// otherwise as JSON. Native arrays are encoded by the Dialect, or as Postgres
+// arrays without a dialect.
 type Array struct {
 	V interface{}
 	Opts
@@ -661,11 +789,29 @@ type Array struct {
 
 // Scan implements the Scanner interface.
 func (a Array) Scan(src interface{}) error {
-	if !a.UseArrayInsteadOfJSON {
+	switch {
+	case !a.UseArrayInsteadOfJSON:
 		return JSON{a.V}.Scan(src)
+	case a.Dialect != nil:
+		return a.Dialect.ScanArray(a.V, src)
 	}
+	return ScanPostgresArray(a.V, src)
+}
 
-	switch v := a.V.(type) {
+// Value implements the driver Valuer interface.
+func (a Array) Value() (driver.Value, error) {
+	switch {
+	case !a.UseArrayInsteadOfJSON:
+		return JSON{a.V}.Value()
+	case a.Dialect != nil:
+		return a.Dialect.ArrayValue(a.V)
+	}
+	return PostgresArrayValue(a.V)
+}
+
+// ScanPostgresArray decodes a Postgres array into v, a pointer to a slice.
+func ScanPostgresArray(v interface{}, src interface{}) error {
+	switch v := v.(type) {
 	case *[]int64:
 		return (*pq.Int64Array)(v).Scan(src)
 
@@ -725,7 +871,7 @@ func (a Array) Scan(src interface{}) error {
 		return nil
 	}
 
-	return pq.Array(a.V).Scan(src)
+	return pq.Array(v).Scan(src)
 }
 
 var timeLayout = `2006-01-02 15:04:05.000`
@@ -739,10 +885,9 @@ func pTime(t time.Time) *time.Time {
 	return &t
 }
 
-// Value implements the driver Valuer interface.
-func (a Array) Value() (driver.Value, error) {
-	v, err := pq.Array(a.V).Value()
-	return v, err
+// PostgresArrayValue encodes v, a slice, as a Postgres array.
+func PostgresArrayValue(v interface{}) (driver.Value, error) {
+	return pq.Array(v).Value()
 }
 
 // Map ...
@@ -763,29 +908,16 @@ func (m Map) SQLUpdate(w SQLWriter) error {
 func (m Map) SQLUpdateAll(w SQLWriter) error {
 	w.WriteRawString(`UPDATE `)
 	w.WriteName(m.Table)
-	switch len(m.M) {
-	case 0:
+	if len(m.M) == 0 {
 		return ErrNoColumn
-	case 1:
-		w.WriteRawString(` SET `)
-		for k, v := range m.M {
-			w.WriteName(k)
-			w.WriteArg(v)
-		}
-		w.WriteRawString(` = `)
-		w.WriteMarker()
-	default:
-		w.WriteQueryString(` SET (`)
-		for k, v := range m.M {
-			w.WriteName(k)
-			w.WriteByte(',')
-			w.WriteArg(v)
-		}
-		w.TrimLast(1)
-		w.WriteRawString(`) = (`)
-		w.WriteMarkers(len(m.M))
-		w.WriteRawString(`)`)
 	}
+	cols := make([]string, 0, len(m.M))
+	for k, v := range m.M {
+		cols = append(cols, k)
+		w.WriteArg(v)
+	}
+	w.WriteRawString(` SET `)
+	w.WriteSetColumns(cols)
 	return nil
 }
 
@@ -807,6 +939,15 @@ func WriteCols(w SQLWriter, prefix string, cols string) {
 	}
 }
 
+// SplitColumn splits a column qualified by a table alias, e.g. "u.id", into the
+// alias and the column name.
+func SplitColumn(col string) (alias, name string) {
+	if i := strings.IndexByte(col, '.'); i >= 0 {
+		return col[:i], col[i+1:]
+	}
+	return "", col
+}
+
 // Ternary ...
 func Ternary(cond bool, exp1, exp2 interface{}) interface{} {
 	if cond {
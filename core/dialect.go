@@ -0,0 +1,50 @@
+package core
+
+import "database/sql/driver"
+
+// Dialect describes the syntax and value encoding of a database. The writer,
+// the query builder and the generated code consult it instead of assuming
+// Postgres or MySQL.
+type Dialect interface {
+	// Name returns the name of the dialect, e.g. "postgres".
+	Name() string
+
+	// Quote returns the character for quoting identifiers.
+	Quote() byte
+
+	// AppendMarker appends the placeholder of the n-th argument, starting
+	// from 1.
+	AppendMarker(b []byte, n int64) []byte
+
+	// TupleUpdate reports whether UPDATE ... SET (a,b) = (?,?) is supported.
+	TupleUpdate() bool
+
+	// Returning reports whether INSERT and UPDATE support RETURNING.
+	Returning() bool
+
+	// UpdateFrom reports whether UPDATE ... FROM is supported.
+	UpdateFrom() bool
+
+	// WriteOnConflict writes the conflict clause of an upsert. The conflicting
+	// rows are left unchanged when updateCols is empty, otherwise updateCols
+	// are set to the new values.
+	WriteOnConflict(w SQLWriter, conflictCols []string, updateCols []string)
+
+	// WriteLimit writes the LIMIT and OFFSET clauses. Either may be empty.
+	WriteLimit(w SQLWriter, limit string, offset string)
+
+	// ArrayValue encodes a slice of basic types or times for Array.
+	ArrayValue(v interface{}) (driver.Value, error)
+
+	// ScanArray decodes src into v, a pointer to a slice of basic types or
+	// times, for Array.
+	ScanArray(v interface{}, src interface{}) error
+
+	// Retryable reports whether err is a transient failure, like a
+	// serialization failure or a deadlock, after which the transaction can be
+	// retried.
+	Retryable(err error) bool
+
+	// MaxParams returns the maximum number of parameters in a statement.
+	MaxParams() int
+}
@@ -8,7 +8,7 @@ import (
 sqlgen:
   generate User
   generate UserSubset from "user"
-  generate UserInfo
+  generate UserInfo (pk user_id)
   generate UserUnion
     from "user"      as u
     full join "user_info" as ui on u.id = ui.user_id
@@ -23,11 +23,11 @@ sqlgen:
 
 //go:generate bash -c "rm sql.gen.go || true"
 //go:generate go install github.com/ng-vu/sqlgen/cmd/sqlgen
-//go:generate sqlgen -o sql.gen.go
+//go:generate sqlgen -f model.sqlgen -o sql.gen.go
 //go:generate goimports -w sql.gen.go
 
 type User struct {
-	ID        string
+	ID        string `sq:"pk"`
 	Name      string
 	CreatedAt time.Time
 	UpdatedAt *time.Time
@@ -43,6 +43,9 @@ type User struct {
 	PInt     *int
 	PInt64   *int64
 	PString  *string
+
+	Info  *UserInfo `sq:"preload,fkey:'user_id'"`
+	Roles []*Role   `sq:"preload,through:'user_role',fkey:'user_id',ref:'role_id'"`
 }
 
 type UserSubset struct {
@@ -160,3 +163,55 @@ type UserInline struct {
 	Inline    Address  `sq:"inline"`
 	PtrInline *Address `sq:"inline"`
 }
+
+type Account struct {
+	ID    string `sq:"pk"`
+	Name  string
+	Users []*AccountUser `sq:"preload,fkey:'account_id'"`
+
+	Invoices []*Invoice `sq:"preload,fkey:'account_id'"`
+}
+
+type AccountUser struct {
+	AccountID string `sq:"pk"`
+	UserID    string `sq:"pk"`
+	Role      string
+
+	User        *User                    `sq:"preload,fkey:'user_id'"`
+	Permissions []*AccountUserPermission `sq:"preload,fkey:'account_id,user_id'"`
+}
+
+type AccountUserPermission struct {
+	AccountID  string
+	UserID     string
+	Permission string
+}
+
+type Invoice struct {
+	ID         string `sq:"pk"`
+	AccountID  string
+	Amount     int64
+	ApprovedBy *string
+
+	Account       *Account `sq:"preload,fkey:'account_id'"`
+	Approver      *User    `sq:"preload,fkey:'approved_by'"`
+	ApproverRoles []*Role  `sq:"preload,through:'invoice_role',fkey:'invoice_id',ref:'role_id'"`
+}
+
+type Role struct {
+	ID   string `sq:"pk"`
+	Name string
+}
+
+type Setting struct {
+	Key       string `sq:"pk"`
+	Value     string
+	CreatedAt time.Time `sq:"create"`
+	UpdatedAt time.Time `sq:"update"`
+}
+
+type Event struct {
+	ID        int64 `sq:"pk"`
+	Name      string
+	CreatedAt time.Time `sq:"create"`
+}
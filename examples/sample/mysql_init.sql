@@ -0,0 +1,2 @@
+CREATE DATABASE IF NOT EXISTS billing;
+GRANT ALL PRIVILEGES ON billing.* TO 'sqlgen'@'%';
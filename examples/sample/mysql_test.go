@@ -0,0 +1,227 @@
+package test
+
+import (
+	"strconv"
+	"testing"
+	"time"
+
+	_ "github.com/go-sql-driver/mysql"
+	. "github.com/smartystreets/goconvey/convey"
+
+	sq "github.com/ng-vu/sqlgen/typesafe/sq"
+)
+
+// mysqlConnStr connects to the MySQL of docker-compose. ANSI_QUOTES lets the
+// raw queries of the tests quote identifiers with double quotes.
+const mysqlConnStr = "sqlgen:sqlgen@tcp(127.0.0.1:13306)/sqlgen?parseTime=true&loc=UTC&multiStatements=true" +
+	"&sql_mode=CONCAT(@@sql_mode,',ANSI_QUOTES')"
+
+// isMySQL reports whether the tests run on the MySQL of docker-compose.
+func isMySQL() bool {
+	return db.Opts().Dialect.Name() == sq.MySQL.Name()
+}
+
+// ConveyNotMySQL is Convey for tests which need the features MySQL lacks, like
+// FULL JOIN and RETURNING.
+func ConveyNotMySQL(name string, action func()) {
+	if isMySQL() {
+		SkipConvey(name, action)
+	} else {
+		Convey(name, action)
+	}
+}
+
+// connectMySQL connects to MySQL. The schema billing is the database of the
+// same name, which is created by mysql_init.sql of docker-compose.
+func connectMySQL(opts ...sq.Option) *sq.Database {
+	return sq.MustConnect("mysql", mysqlConnStr, opts...)
+}
+
+// InitMySQLSchema creates the tables of the sample in MySQL.
+func InitMySQLSchema() {
+	db.MustExec(`
+		DROP TABLE IF EXISTS "user_info", "complex_info", "user";
+		DROP TABLE IF EXISTS "account", "account_user", "account_user_permission";
+		DROP TABLE IF EXISTS "role", "user_role", "setting", "event";
+		DROP TABLE IF EXISTS billing."invoice_v2", billing."invoice_role";
+		CREATE TABLE "user" (
+			id         VARCHAR(255) PRIMARY KEY,
+			name       TEXT,
+			created_at DATETIME(6),
+			updated_at DATETIME(6),
+			"bool"     BOOLEAN,
+			float64    DOUBLE,
+			"int"      INTEGER,
+			int64      BIGINT,
+			string     TEXT,
+			p_bool     BOOLEAN,
+			p_float64  DOUBLE,
+			p_int      INTEGER,
+			p_int64    BIGINT,
+			p_string   TEXT
+		);
+		CREATE TABLE "user_info" (
+			user_id    VARCHAR(255) PRIMARY KEY,
+			metadata   TEXT,
+			"bool"     BOOLEAN,
+			float64    DOUBLE,
+			"int"      INTEGER,
+			int64      BIGINT,
+			string     TEXT,
+			p_bool     BOOLEAN,
+			p_float64  DOUBLE,
+			p_int      INTEGER,
+			p_int64    BIGINT,
+			p_string   TEXT
+		);
+		CREATE TABLE "complex_info" (
+			id VARCHAR(255) PRIMARY KEY,
+			address         TEXT,
+			p_address       TEXT,
+			metadata        TEXT,
+			ints            TEXT,
+			int64s          TEXT,
+			strings         TEXT,
+			times           TEXT,
+			times_p         TEXT,
+			alias_string    TEXT,
+			alias_int64     BIGINT,
+			alias_int       INTEGER,
+			alias_bool      BOOLEAN,
+			alias_float64   DOUBLE,
+			alias_p_string  TEXT,
+			alias_p_int64   BIGINT,
+			alias_p_int     INTEGER,
+			alias_p_bool    BOOLEAN,
+			alias_p_float64 DOUBLE
+		);
+		CREATE TABLE "account" (
+			id   VARCHAR(255) PRIMARY KEY,
+			name TEXT
+		);
+		CREATE TABLE "account_user" (
+			account_id VARCHAR(255),
+			user_id    VARCHAR(255),
+			role       TEXT,
+			PRIMARY KEY (account_id, user_id)
+		);
+		CREATE TABLE "account_user_permission" (
+			account_id VARCHAR(255),
+			user_id    VARCHAR(255),
+			permission TEXT
+		);
+		CREATE TABLE "role" (
+			id   VARCHAR(255) PRIMARY KEY,
+			name TEXT
+		);
+		CREATE TABLE "user_role" (
+			user_id VARCHAR(255),
+			role_id VARCHAR(255),
+			PRIMARY KEY (user_id, role_id)
+		);
+		CREATE TABLE "setting" (
+			"key"      VARCHAR(255) PRIMARY KEY,
+			value      TEXT,
+			created_at DATETIME(6),
+			updated_at DATETIME(6)
+		);
+		CREATE TABLE "event" (
+			id         BIGINT AUTO_INCREMENT PRIMARY KEY,
+			name       TEXT,
+			created_at DATETIME(6)
+		);
+		CREATE TABLE billing."invoice_v2" (
+			id          VARCHAR(255) PRIMARY KEY,
+			account_id  VARCHAR(255),
+			amount      BIGINT,
+			approved_by VARCHAR(255)
+		);
+		CREATE TABLE billing."invoice_role" (
+			invoice_id VARCHAR(255),
+			role_id    VARCHAR(255),
+			PRIMARY KEY (invoice_id, role_id)
+		);
+	`)
+}
+
+// mysqlValue converts a value scanned by the MySQL driver, which returns text
+// and numbers of queries without arguments as []byte and booleans as integers,
+// to the value returned by the other drivers.
+func mysqlValue(typ string, v interface{}) interface{} {
+	b, isBytes := v.([]byte)
+	switch typ {
+	case "VARCHAR", "CHAR", "TEXT":
+		if isBytes {
+			return string(b)
+		}
+	case "TINYINT":
+		switch v := v.(type) {
+		case int64:
+			return v != 0
+		case []byte:
+			return string(v) != "0"
+		}
+	case "INT", "BIGINT":
+		if isBytes {
+			n, _ := strconv.ParseInt(string(b), 10, 64)
+			return n
+		}
+	case "DOUBLE":
+		if isBytes {
+			f, _ := strconv.ParseFloat(string(b), 64)
+			return f
+		}
+	}
+	return v
+}
+
+func TestMySQL(t *testing.T) {
+	if !isMySQL() {
+		t.Skip("The tests do not run on MySQL")
+	}
+	t0 := time.Date(2020, 10, 11, 8, 9, 10, 123e6, time.UTC)
+
+	Convey("MySQL", t, func() {
+		Reset(func() {
+			truncate(`"user"`, `"user_info"`, `"event"`)
+		})
+
+		Convey("Offset without limit", func() {
+			_, err := db.Insert(&User{ID: "1000"}, &User{ID: "1001"})
+			So(err, ShouldBeNil)
+
+			query, _, err := db.OrderBy("id").Offset(1).BuildFind(&Users{})
+			So(err, ShouldBeNil)
+			So(query, ShouldEndWith, "ORDER BY `id` LIMIT 18446744073709551615 OFFSET 1")
+
+			var items Users
+			So(db.OrderBy("id").Offset(1).Find(&items), ShouldBeNil)
+			So(len(items), ShouldEqual, 1)
+			So(items[0].ID, ShouldEqual, "1001")
+		})
+		Convey("LastInsertId", func() {
+			event := &Event{Name: "e1"}
+			_, err := db.Insert(event)
+			So(err, ShouldBeNil)
+			So(event.ID, ShouldBeGreaterThan, 0)
+
+			Convey("Returning reloads the row", func() {
+				event := &Event{Name: "e2", CreatedAt: t0}
+				_, err := db.Returning().Insert(event)
+				So(err, ShouldBeNil)
+				So(event.ID, ShouldBeGreaterThan, 0)
+				So(event.CreatedAt, ShouldEqual, t0)
+			})
+		})
+		Convey("Returning reloads a row updated with unchanged values", func() {
+			_, err := db.Insert(&UserInfo{UserID: "1000", Int: 100, String: "s"})
+			So(err, ShouldBeNil)
+
+			info := &UserInfo{UserID: "1000", Int: 100}
+			n, err := db.Returning().Update(info)
+			So(err, ShouldBeNil)
+			So(n, ShouldEqual, 0)
+			So(info.String, ShouldEqual, "s")
+		})
+	})
+}
@@ -19,11 +19,15 @@ const __sqlUser_ListCols = "\"id\",\"name\",\"created_at\",\"updated_at\",\"bool
 const __sqlUser_Insert = "INSERT INTO \"user\" (" + __sqlUser_ListCols + ") VALUES"
 const __sqlUser_Select = "SELECT " + __sqlUser_ListCols + " FROM \"user\""
 const __sqlUser_Select_history = "SELECT " + __sqlUser_ListCols + " FROM history.\"user\""
-const __sqlUser_UpdateAll = "UPDATE \"user\" SET (" + __sqlUser_ListCols + ")"
 
 func (m *User) SQLTableName() string { return "user" }
 func (m Users) SQLTableName() string { return "user" }
 
+var __sqlUser_Cols = []string{"id", "name", "created_at", "updated_at", "bool", "float64", "int", "int64", "string", "p_bool", "p_float64", "p_int", "p_int64", "p_string"}
+
+func (_ *User) SQLColumns() []string { return __sqlUser_Cols }
+func (_ Users) SQLColumns() []string { return __sqlUser_Cols }
+
 func (m *User) SQLArgs(opts core.Opts, create bool) []interface{} {
 	return []interface{}{
 		core.String(m.ID),
@@ -62,6 +66,40 @@ func (m *User) SQLScanArgs(opts core.Opts) []interface{} {
 	}
 }
 
+func (m *User) SQLColumnValue(opts core.Opts, col string) (interface{}, bool) {
+	switch col {
+	case "id":
+		return core.String(m.ID), true
+	case "name":
+		return core.String(m.Name), true
+	case "created_at":
+		return core.Time(m.CreatedAt), true
+	case "updated_at":
+		return m.UpdatedAt, true
+	case "bool":
+		return core.Bool(m.Bool), true
+	case "float64":
+		return core.Float64(m.Float64), true
+	case "int":
+		return core.Int(m.Int), true
+	case "int64":
+		return core.Int64(m.Int64), true
+	case "string":
+		return core.String(m.String), true
+	case "p_bool":
+		return m.PBool, true
+	case "p_float64":
+		return m.PFloat64, true
+	case "p_int":
+		return m.PInt, true
+	case "p_int64":
+		return m.PInt64, true
+	case "p_string":
+		return m.PString, true
+	}
+	return nil, false
+}
+
 func (m *User) SQLScan(opts core.Opts, row *sql.Row) error {
 	return row.Scan(m.SQLScanArgs(opts)...)
 }
@@ -114,6 +152,36 @@ func (ms Users) SQLInsert(w SQLWriter) error {
 	return nil
 }
 
+func (m *User) SQLUpsert(w SQLWriter, conflictCols []string, updateCols []string) error {
+	if len(conflictCols) == 0 {
+		conflictCols = __sqlUser_PK
+	}
+	if err := m.SQLInsert(w); err != nil {
+		return err
+	}
+	w.WriteOnConflict(conflictCols, updateCols)
+	return nil
+}
+
+func (ms Users) SQLUpsert(w SQLWriter, conflictCols []string, updateCols []string) error {
+	if len(conflictCols) == 0 {
+		conflictCols = __sqlUser_PK
+	}
+	if err := ms.SQLInsert(w); err != nil {
+		return err
+	}
+	w.WriteOnConflict(conflictCols, updateCols)
+	return nil
+}
+
+func (_ *User) SQLUpsertColumns(skipCreated bool) []string {
+	return []string{"name", "created_at", "updated_at", "bool", "float64", "int", "int64", "string", "p_bool", "p_float64", "p_int", "p_int64", "p_string"}
+}
+
+func (_ Users) SQLUpsertColumns(skipCreated bool) []string {
+	return (*User)(nil).SQLUpsertColumns(skipCreated)
+}
+
 func (m *User) SQLUpdate(w SQLWriter) error {
 	now, opts := time.Now(), w.Opts()
 	_, _ = now, opts // suppress unused error
@@ -241,25 +309,243 @@ func (m *User) SQLUpdate(w SQLWriter) error {
 }
 
 func (m *User) SQLUpdateAll(w SQLWriter) error {
-	w.WriteQueryString(__sqlUser_UpdateAll)
-	w.WriteRawString(" = (")
-	w.WriteMarkers(14)
-	w.WriteByte(')')
+	w.WriteRawString("UPDATE ")
+	w.WriteName("user")
+	w.WriteRawString(" SET ")
+	w.WriteSetColumns(__sqlUser_Cols)
 	w.WriteArgs(m.SQLArgs(w.Opts(), false))
 	return nil
 }
 
+var __sqlUser_PK = []string{"id"}
+
+type UserKey struct {
+	ID string
+}
+
+func (m *User) SQLKey() UserKey {
+	return UserKey{
+		ID: m.ID,
+	}
+}
+
+func (_ *User) SQLPrimaryKeyColumns() []string { return __sqlUser_PK }
+
+func (m *User) SQLPrimaryKey(w SQLWriter) error {
+	if !(m.ID != "") {
+		return core.InvalidArgumentError("missing id")
+	}
+	w.WriteName("id")
+	w.WriteRawString(" = ")
+	w.WriteMarker()
+	w.WriteArg(m.ID)
+	return nil
+}
+
+func (m *User) GetByPK(q sq.CommonQuery, pk string) (bool, error) {
+	m.ID = pk
+	return q.Get(m, sq.WriterToFunc(m.SQLPrimaryKey))
+}
+
+func (m *User) GetByKey(q sq.CommonQuery, key UserKey) (bool, error) {
+	m.ID = key.ID
+	return q.Get(m, sq.WriterToFunc(m.SQLPrimaryKey))
+}
+
+func (ms *Users) FindByKeys(q sq.CommonQuery, keys ...UserKey) error {
+	args := make([]interface{}, 0, len(keys)*1)
+	for _, key := range keys {
+		args = append(args, key.ID)
+	}
+	return q.Where(sq.Ins(__sqlUser_PK, args...)).Find(ms)
+}
+
+func (m *User) UpdateByPK(q sq.CommonQuery) (int64, error) {
+	return q.Where(sq.WriterToFunc(m.SQLPrimaryKey)).Update(m)
+}
+
+func (m *User) DeleteByPK(q sq.CommonQuery) (int64, error) {
+	return q.Where(sq.WriterToFunc(m.SQLPrimaryKey)).Delete(m)
+}
+
+func (m *User) SQLPreload(table string) *core.PreloadDesc {
+	switch table {
+	case "user_info":
+		var items UserInfoes
+		return &core.PreloadDesc{
+			Fkey:  "user_id",
+			IDs:   []interface{}{m.ID},
+			Items: &items,
+		}
+	case "role":
+		return Users{m}.SQLPreload(table)
+	default:
+		return nil
+	}
+}
+
+func (m Users) SQLPreload(table string) *core.PreloadDesc {
+	switch table {
+	case "user_info":
+		ids := make([]interface{}, 0, len(m)*1)
+		for _, item := range m {
+			ids = append(ids, item.ID)
+		}
+		var items UserInfoes
+		return &core.PreloadDesc{
+			Fkey:  "user_id",
+			IDs:   ids,
+			Items: &items,
+		}
+	case "role":
+		ids := make([]interface{}, 0, len(m))
+		for _, item := range m {
+			ids = append(ids, item.ID)
+		}
+		type link struct {
+			Fkey string
+			Ref  string
+		}
+		var links []link
+		var items Roles
+		return &core.PreloadDesc{
+			Fkey:    "user_id",
+			IDs:     ids,
+			Items:   &items,
+			Through: "user_role",
+			Ref:     "role_id",
+			RefKey:  "id",
+			Link: func() []interface{} {
+				links = append(links, link{})
+				l := &links[len(links)-1]
+				return []interface{}{&l.Fkey, &l.Ref}
+			},
+			RefIDs: func() []interface{} {
+				refs := make([]interface{}, len(links))
+				for i, l := range links {
+					refs[i] = l.Ref
+				}
+				return refs
+			},
+			Populate: func() error {
+				mapKey := make(map[string]*User, len(m))
+				for _, item := range m {
+					mapKey[item.ID] = item
+				}
+				mapItem := make(map[string]*Role, len(items))
+				for _, item := range items {
+					mapItem[item.ID] = item
+				}
+				for _, l := range links {
+					mitem, item := mapKey[l.Fkey], mapItem[l.Ref]
+					if mitem != nil && item != nil {
+						mitem.Roles = append(mitem.Roles, item)
+					}
+				}
+				return nil
+			},
+		}
+	default:
+		return nil
+	}
+}
+
+func (m *User) SQLPopulate(items core.IFind) error {
+	switch items := items.(type) {
+	case *UserInfoes:
+		if len(*items) != 0 {
+			m.Info = (*items)[0]
+		}
+		return nil
+	default:
+		return core.Errorf("can not populate %T into %T", items, m)
+	}
+}
+
+func (m Users) SQLPopulate(items core.IFind) error {
+	mapKey := make(map[UserKey]*User)
+	for _, item := range m {
+		mapKey[item.SQLKey()] = item
+	}
+
+	switch items := items.(type) {
+	case *UserInfoes:
+		for _, item := range *items {
+			mitem := mapKey[UserKey{ID: item.UserID}]
+			if mitem == nil {
+				return core.Errorf("can not populate id %v", UserKey{ID: item.UserID})
+			}
+			mitem.Info = item
+		}
+		return nil
+	default:
+		return core.Errorf("can not populate %T into %T", items, m)
+	}
+}
+
+// AddRoles links Roles to m by inserting into "user_role".
+func (m *User) AddRoles(tx sq.Tx, items ...*Role) (int64, error) {
+	if len(items) == 0 {
+		return 0, nil
+	}
+	res, err := tx.SQL(sq.WriterToFunc(func(w SQLWriter) error {
+		w.WriteRawString("INSERT INTO ")
+		w.WriteName("user_role")
+		w.WriteRawString(" (")
+		w.WriteName("user_id")
+		w.WriteByte(',')
+		w.WriteName("role_id")
+		w.WriteRawString(") VALUES ")
+		for _, item := range items {
+			w.WriteByte('(')
+			w.WriteMarkers(2)
+			w.WriteArgs([]interface{}{m.ID, item.ID})
+			w.WriteRawString("),")
+		}
+		w.TrimLast(1)
+		return nil
+	})).Exec()
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
+
+// RemoveRoles unlinks Roles from m by deleting from "user_role".
+func (m *User) RemoveRoles(tx sq.Tx, items ...*Role) (int64, error) {
+	if len(items) == 0 {
+		return 0, nil
+	}
+	refs := make([]interface{}, len(items))
+	for i, item := range items {
+		refs[i] = item.ID
+	}
+	res, err := tx.SQL(sq.WriterToFunc(func(w SQLWriter) error {
+		w.WriteRawString("DELETE FROM ")
+		w.WriteName("user_role")
+		return nil
+	})).In("user_id", m.ID).In("role_id", refs).Exec()
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
+
 type UserSubsets []*UserSubset
 
-const __sqlUserSubset_Table = "user_subset"
+const __sqlUserSubset_Table = "user"
 const __sqlUserSubset_ListCols = "\"id\",\"bool\",\"float64\",\"int\",\"int64\",\"string\",\"p_bool\",\"p_float64\",\"p_int\",\"p_int64\",\"p_string\""
-const __sqlUserSubset_Insert = "INSERT INTO \"user_subset\" (" + __sqlUserSubset_ListCols + ") VALUES"
-const __sqlUserSubset_Select = "SELECT " + __sqlUserSubset_ListCols + " FROM \"user_subset\""
-const __sqlUserSubset_Select_history = "SELECT " + __sqlUserSubset_ListCols + " FROM history.\"user_subset\""
-const __sqlUserSubset_UpdateAll = "UPDATE \"user_subset\" SET (" + __sqlUserSubset_ListCols + ")"
+const __sqlUserSubset_Insert = "INSERT INTO \"user\" (" + __sqlUserSubset_ListCols + ") VALUES"
+const __sqlUserSubset_Select = "SELECT " + __sqlUserSubset_ListCols + " FROM \"user\""
+const __sqlUserSubset_Select_history = "SELECT " + __sqlUserSubset_ListCols + " FROM history.\"user\""
+
+func (m *UserSubset) SQLTableName() string { return "user" }
+func (m UserSubsets) SQLTableName() string { return "user" }
 
-func (m *UserSubset) SQLTableName() string { return "user_subset" }
-func (m UserSubsets) SQLTableName() string { return "user_subset" }
+var __sqlUserSubset_Cols = []string{"id", "bool", "float64", "int", "int64", "string", "p_bool", "p_float64", "p_int", "p_int64", "p_string"}
+
+func (_ *UserSubset) SQLColumns() []string { return __sqlUserSubset_Cols }
+func (_ UserSubsets) SQLColumns() []string { return __sqlUserSubset_Cols }
 
 func (m *UserSubset) SQLArgs(opts core.Opts, create bool) []interface{} {
 	return []interface{}{
@@ -293,6 +579,34 @@ func (m *UserSubset) SQLScanArgs(opts core.Opts) []interface{} {
 	}
 }
 
+func (m *UserSubset) SQLColumnValue(opts core.Opts, col string) (interface{}, bool) {
+	switch col {
+	case "id":
+		return core.String(m.ID), true
+	case "bool":
+		return core.Bool(m.Bool), true
+	case "float64":
+		return core.Float64(m.Float64), true
+	case "int":
+		return core.Int(m.Int), true
+	case "int64":
+		return core.Int64(m.Int64), true
+	case "string":
+		return core.String(m.String), true
+	case "p_bool":
+		return m.PBool, true
+	case "p_float64":
+		return m.PFloat64, true
+	case "p_int":
+		return m.PInt, true
+	case "p_int64":
+		return m.PInt64, true
+	case "p_string":
+		return m.PString, true
+	}
+	return nil, false
+}
+
 func (m *UserSubset) SQLScan(opts core.Opts, row *sql.Row) error {
 	return row.Scan(m.SQLScanArgs(opts)...)
 }
@@ -345,12 +659,42 @@ func (ms UserSubsets) SQLInsert(w SQLWriter) error {
 	return nil
 }
 
+func (m *UserSubset) SQLUpsert(w SQLWriter, conflictCols []string, updateCols []string) error {
+	if len(conflictCols) == 0 {
+		return core.Errorf("sqlgen: upsert UserSubset without primary key requires OnConflict columns")
+	}
+	if err := m.SQLInsert(w); err != nil {
+		return err
+	}
+	w.WriteOnConflict(conflictCols, updateCols)
+	return nil
+}
+
+func (ms UserSubsets) SQLUpsert(w SQLWriter, conflictCols []string, updateCols []string) error {
+	if len(conflictCols) == 0 {
+		return core.Errorf("sqlgen: upsert UserSubset without primary key requires OnConflict columns")
+	}
+	if err := ms.SQLInsert(w); err != nil {
+		return err
+	}
+	w.WriteOnConflict(conflictCols, updateCols)
+	return nil
+}
+
+func (_ *UserSubset) SQLUpsertColumns(skipCreated bool) []string {
+	return []string{"id", "bool", "float64", "int", "int64", "string", "p_bool", "p_float64", "p_int", "p_int64", "p_string"}
+}
+
+func (_ UserSubsets) SQLUpsertColumns(skipCreated bool) []string {
+	return (*UserSubset)(nil).SQLUpsertColumns(skipCreated)
+}
+
 func (m *UserSubset) SQLUpdate(w SQLWriter) error {
 	now, opts := time.Now(), w.Opts()
 	_, _ = now, opts // suppress unused error
 	var flag bool
 	w.WriteRawString("UPDATE ")
-	w.WriteName("user_subset")
+	w.WriteName("user")
 	w.WriteRawString(" SET ")
 	if m.ID != "" {
 		flag = true
@@ -448,10 +792,10 @@ func (m *UserSubset) SQLUpdate(w SQLWriter) error {
 }
 
 func (m *UserSubset) SQLUpdateAll(w SQLWriter) error {
-	w.WriteQueryString(__sqlUserSubset_UpdateAll)
-	w.WriteRawString(" = (")
-	w.WriteMarkers(11)
-	w.WriteByte(')')
+	w.WriteRawString("UPDATE ")
+	w.WriteName("user")
+	w.WriteRawString(" SET ")
+	w.WriteSetColumns(__sqlUserSubset_Cols)
 	w.WriteArgs(m.SQLArgs(w.Opts(), false))
 	return nil
 }
@@ -463,11 +807,15 @@ const __sqlUserInfo_ListCols = "\"user_id\",\"metadata\",\"bool\",\"float64\",\"
 const __sqlUserInfo_Insert = "INSERT INTO \"user_info\" (" + __sqlUserInfo_ListCols + ") VALUES"
 const __sqlUserInfo_Select = "SELECT " + __sqlUserInfo_ListCols + " FROM \"user_info\""
 const __sqlUserInfo_Select_history = "SELECT " + __sqlUserInfo_ListCols + " FROM history.\"user_info\""
-const __sqlUserInfo_UpdateAll = "UPDATE \"user_info\" SET (" + __sqlUserInfo_ListCols + ")"
 
 func (m *UserInfo) SQLTableName() string  { return "user_info" }
 func (m UserInfoes) SQLTableName() string { return "user_info" }
 
+var __sqlUserInfo_Cols = []string{"user_id", "metadata", "bool", "float64", "int", "int64", "string", "p_bool", "p_float64", "p_int", "p_int64", "p_string"}
+
+func (_ *UserInfo) SQLColumns() []string  { return __sqlUserInfo_Cols }
+func (_ UserInfoes) SQLColumns() []string { return __sqlUserInfo_Cols }
+
 func (m *UserInfo) SQLArgs(opts core.Opts, create bool) []interface{} {
 	return []interface{}{
 		core.String(m.UserID),
@@ -502,6 +850,36 @@ func (m *UserInfo) SQLScanArgs(opts core.Opts) []interface{} {
 	}
 }
 
+func (m *UserInfo) SQLColumnValue(opts core.Opts, col string) (interface{}, bool) {
+	switch col {
+	case "user_id":
+		return core.String(m.UserID), true
+	case "metadata":
+		return core.String(m.Metadata), true
+	case "bool":
+		return core.Bool(m.Bool), true
+	case "float64":
+		return core.Float64(m.Float64), true
+	case "int":
+		return core.Int(m.Int), true
+	case "int64":
+		return core.Int64(m.Int64), true
+	case "string":
+		return core.String(m.String), true
+	case "p_bool":
+		return m.PBool, true
+	case "p_float64":
+		return m.PFloat64, true
+	case "p_int":
+		return m.PInt, true
+	case "p_int64":
+		return m.PInt64, true
+	case "p_string":
+		return m.PString, true
+	}
+	return nil, false
+}
+
 func (m *UserInfo) SQLScan(opts core.Opts, row *sql.Row) error {
 	return row.Scan(m.SQLScanArgs(opts)...)
 }
@@ -554,6 +932,36 @@ func (ms UserInfoes) SQLInsert(w SQLWriter) error {
 	return nil
 }
 
+func (m *UserInfo) SQLUpsert(w SQLWriter, conflictCols []string, updateCols []string) error {
+	if len(conflictCols) == 0 {
+		conflictCols = __sqlUserInfo_PK
+	}
+	if err := m.SQLInsert(w); err != nil {
+		return err
+	}
+	w.WriteOnConflict(conflictCols, updateCols)
+	return nil
+}
+
+func (ms UserInfoes) SQLUpsert(w SQLWriter, conflictCols []string, updateCols []string) error {
+	if len(conflictCols) == 0 {
+		conflictCols = __sqlUserInfo_PK
+	}
+	if err := ms.SQLInsert(w); err != nil {
+		return err
+	}
+	w.WriteOnConflict(conflictCols, updateCols)
+	return nil
+}
+
+func (_ *UserInfo) SQLUpsertColumns(skipCreated bool) []string {
+	return []string{"metadata", "bool", "float64", "int", "int64", "string", "p_bool", "p_float64", "p_int", "p_int64", "p_string"}
+}
+
+func (_ UserInfoes) SQLUpsertColumns(skipCreated bool) []string {
+	return (*UserInfo)(nil).SQLUpsertColumns(skipCreated)
+}
+
 func (m *UserInfo) SQLUpdate(w SQLWriter) error {
 	now, opts := time.Now(), w.Opts()
 	_, _ = now, opts // suppress unused error
@@ -665,14 +1073,65 @@ func (m *UserInfo) SQLUpdate(w SQLWriter) error {
 }
 
 func (m *UserInfo) SQLUpdateAll(w SQLWriter) error {
-	w.WriteQueryString(__sqlUserInfo_UpdateAll)
-	w.WriteRawString(" = (")
-	w.WriteMarkers(12)
-	w.WriteByte(')')
+	w.WriteRawString("UPDATE ")
+	w.WriteName("user_info")
+	w.WriteRawString(" SET ")
+	w.WriteSetColumns(__sqlUserInfo_Cols)
 	w.WriteArgs(m.SQLArgs(w.Opts(), false))
 	return nil
 }
 
+var __sqlUserInfo_PK = []string{"user_id"}
+
+type UserInfoKey struct {
+	UserID string
+}
+
+func (m *UserInfo) SQLKey() UserInfoKey {
+	return UserInfoKey{
+		UserID: m.UserID,
+	}
+}
+
+func (_ *UserInfo) SQLPrimaryKeyColumns() []string { return __sqlUserInfo_PK }
+
+func (m *UserInfo) SQLPrimaryKey(w SQLWriter) error {
+	if !(m.UserID != "") {
+		return core.InvalidArgumentError("missing user_id")
+	}
+	w.WriteName("user_id")
+	w.WriteRawString(" = ")
+	w.WriteMarker()
+	w.WriteArg(m.UserID)
+	return nil
+}
+
+func (m *UserInfo) GetByPK(q sq.CommonQuery, pk string) (bool, error) {
+	m.UserID = pk
+	return q.Get(m, sq.WriterToFunc(m.SQLPrimaryKey))
+}
+
+func (m *UserInfo) GetByKey(q sq.CommonQuery, key UserInfoKey) (bool, error) {
+	m.UserID = key.UserID
+	return q.Get(m, sq.WriterToFunc(m.SQLPrimaryKey))
+}
+
+func (ms *UserInfoes) FindByKeys(q sq.CommonQuery, keys ...UserInfoKey) error {
+	args := make([]interface{}, 0, len(keys)*1)
+	for _, key := range keys {
+		args = append(args, key.UserID)
+	}
+	return q.Where(sq.Ins(__sqlUserInfo_PK, args...)).Find(ms)
+}
+
+func (m *UserInfo) UpdateByPK(q sq.CommonQuery) (int64, error) {
+	return q.Where(sq.WriterToFunc(m.SQLPrimaryKey)).Update(m)
+}
+
+func (m *UserInfo) DeleteByPK(q sq.CommonQuery) (int64, error) {
+	return q.Where(sq.WriterToFunc(m.SQLPrimaryKey)).Delete(m)
+}
+
 type UserUnions []*UserUnion
 
 var __sqlUserUnion_JoinTypes = []sq.JOIN_TYPE{sq.FULL_JOIN}
@@ -762,6 +1221,23 @@ func (m *UserUnion) SQLScanArgs(opts core.Opts) []interface{} {
 	return args
 }
 
+func (m *UserUnion) SQLColumnValue(opts core.Opts, col string) (interface{}, bool) {
+	alias, name := core.SplitColumn(col)
+	switch sq.AS(alias) {
+	case __sqlUserUnion_As:
+		if m.User == nil {
+			return nil, true
+		}
+		return m.User.SQLColumnValue(opts, name)
+	case __sqlUserUnion_JoinAs[0]:
+		if m.UserInfo == nil {
+			return nil, true
+		}
+		return m.UserInfo.SQLColumnValue(opts, name)
+	}
+	return nil, false
+}
+
 type UserUnionMores []*UserUnionMore
 
 var __sqlUserUnionMore_JoinTypes = []sq.JOIN_TYPE{sq.FULL_JOIN, sq.RIGHT_JOIN}
@@ -863,6 +1339,28 @@ func (m *UserUnionMore) SQLScanArgs(opts core.Opts) []interface{} {
 	return args
 }
 
+func (m *UserUnionMore) SQLColumnValue(opts core.Opts, col string) (interface{}, bool) {
+	alias, name := core.SplitColumn(col)
+	switch sq.AS(alias) {
+	case __sqlUserUnionMore_As:
+		if m.User == nil {
+			return nil, true
+		}
+		return m.User.SQLColumnValue(opts, name)
+	case __sqlUserUnionMore_JoinAs[0]:
+		if m.UserInfo == nil {
+			return nil, true
+		}
+		return m.UserInfo.SQLColumnValue(opts, name)
+	case __sqlUserUnionMore_JoinAs[1]:
+		if m.UserSubset == nil {
+			return nil, true
+		}
+		return m.UserSubset.SQLColumnValue(opts, name)
+	}
+	return nil, false
+}
+
 type ComplexInfoes []*ComplexInfo
 
 const __sqlComplexInfo_Table = "complex_info"
@@ -870,11 +1368,15 @@ const __sqlComplexInfo_ListCols = "\"id\",\"address\",\"p_address\",\"metadata\"
 const __sqlComplexInfo_Insert = "INSERT INTO \"complex_info\" (" + __sqlComplexInfo_ListCols + ") VALUES"
 const __sqlComplexInfo_Select = "SELECT " + __sqlComplexInfo_ListCols + " FROM \"complex_info\""
 const __sqlComplexInfo_Select_history = "SELECT " + __sqlComplexInfo_ListCols + " FROM history.\"complex_info\""
-const __sqlComplexInfo_UpdateAll = "UPDATE \"complex_info\" SET (" + __sqlComplexInfo_ListCols + ")"
 
 func (m *ComplexInfo) SQLTableName() string  { return "complex_info" }
 func (m ComplexInfoes) SQLTableName() string { return "complex_info" }
 
+var __sqlComplexInfo_Cols = []string{"id", "address", "p_address", "metadata", "ints", "int64s", "strings", "times", "times_p", "alias_string", "alias_int64", "alias_int", "alias_bool", "alias_float64", "alias_p_string", "alias_p_int64", "alias_p_int", "alias_p_bool", "alias_p_float64"}
+
+func (_ *ComplexInfo) SQLColumns() []string  { return __sqlComplexInfo_Cols }
+func (_ ComplexInfoes) SQLColumns() []string { return __sqlComplexInfo_Cols }
+
 func (m *ComplexInfo) SQLArgs(opts core.Opts, create bool) []interface{} {
 	return []interface{}{
 		core.String(m.ID),
@@ -923,6 +1425,50 @@ func (m *ComplexInfo) SQLScanArgs(opts core.Opts) []interface{} {
 	}
 }
 
+func (m *ComplexInfo) SQLColumnValue(opts core.Opts, col string) (interface{}, bool) {
+	switch col {
+	case "id":
+		return core.String(m.ID), true
+	case "address":
+		return core.JSON{&m.Address}, true
+	case "p_address":
+		return core.JSON{m.PAddress}, true
+	case "metadata":
+		return core.JSON{m.Metadata}, true
+	case "ints":
+		return core.Array{m.Ints, opts}, true
+	case "int64s":
+		return core.Array{m.Int64s, opts}, true
+	case "strings":
+		return core.Array{m.Strings, opts}, true
+	case "times":
+		return core.Array{m.Times, opts}, true
+	case "times_p":
+		return core.Array{m.TimesP, opts}, true
+	case "alias_string":
+		return core.String(m.AliasString), true
+	case "alias_int64":
+		return core.Int64(m.AliasInt64), true
+	case "alias_int":
+		return core.Int(m.AliasInt), true
+	case "alias_bool":
+		return core.Bool(m.AliasBool), true
+	case "alias_float64":
+		return core.Float64(m.AliasFloat64), true
+	case "alias_p_string":
+		return m.AliasPString, true
+	case "alias_p_int64":
+		return m.AliasPInt64, true
+	case "alias_p_int":
+		return m.AliasPInt, true
+	case "alias_p_bool":
+		return m.AliasPBool, true
+	case "alias_p_float64":
+		return m.AliasPFloat64, true
+	}
+	return nil, false
+}
+
 func (m *ComplexInfo) SQLScan(opts core.Opts, row *sql.Row) error {
 	return row.Scan(m.SQLScanArgs(opts)...)
 }
@@ -975,6 +1521,36 @@ func (ms ComplexInfoes) SQLInsert(w SQLWriter) error {
 	return nil
 }
 
+func (m *ComplexInfo) SQLUpsert(w SQLWriter, conflictCols []string, updateCols []string) error {
+	if len(conflictCols) == 0 {
+		return core.Errorf("sqlgen: upsert ComplexInfo without primary key requires OnConflict columns")
+	}
+	if err := m.SQLInsert(w); err != nil {
+		return err
+	}
+	w.WriteOnConflict(conflictCols, updateCols)
+	return nil
+}
+
+func (ms ComplexInfoes) SQLUpsert(w SQLWriter, conflictCols []string, updateCols []string) error {
+	if len(conflictCols) == 0 {
+		return core.Errorf("sqlgen: upsert ComplexInfo without primary key requires OnConflict columns")
+	}
+	if err := ms.SQLInsert(w); err != nil {
+		return err
+	}
+	w.WriteOnConflict(conflictCols, updateCols)
+	return nil
+}
+
+func (_ *ComplexInfo) SQLUpsertColumns(skipCreated bool) []string {
+	return []string{"id", "address", "p_address", "metadata", "ints", "int64s", "strings", "times", "times_p", "alias_string", "alias_int64", "alias_int", "alias_bool", "alias_float64", "alias_p_string", "alias_p_int64", "alias_p_int", "alias_p_bool", "alias_p_float64"}
+}
+
+func (_ ComplexInfoes) SQLUpsertColumns(skipCreated bool) []string {
+	return (*ComplexInfo)(nil).SQLUpsertColumns(skipCreated)
+}
+
 func (m *ComplexInfo) SQLUpdate(w SQLWriter) error {
 	now, opts := time.Now(), w.Opts()
 	_, _ = now, opts // suppress unused error
@@ -1142,10 +1718,10 @@ func (m *ComplexInfo) SQLUpdate(w SQLWriter) error {
 }
 
 func (m *ComplexInfo) SQLUpdateAll(w SQLWriter) error {
-	w.WriteQueryString(__sqlComplexInfo_UpdateAll)
-	w.WriteRawString(" = (")
-	w.WriteMarkers(19)
-	w.WriteByte(')')
+	w.WriteRawString("UPDATE ")
+	w.WriteName("complex_info")
+	w.WriteRawString(" SET ")
+	w.WriteSetColumns(__sqlComplexInfo_Cols)
 	w.WriteArgs(m.SQLArgs(w.Opts(), false))
 	return nil
 }
@@ -1157,11 +1733,15 @@ const __sqlUserTag_ListCols = "\"province\",\"new_name\""
 const __sqlUserTag_Insert = "INSERT INTO \"user_tag\" (" + __sqlUserTag_ListCols + ") VALUES"
 const __sqlUserTag_Select = "SELECT " + __sqlUserTag_ListCols + " FROM \"user_tag\""
 const __sqlUserTag_Select_history = "SELECT " + __sqlUserTag_ListCols + " FROM history.\"user_tag\""
-const __sqlUserTag_UpdateAll = "UPDATE \"user_tag\" SET (" + __sqlUserTag_ListCols + ")"
 
 func (m *UserTag) SQLTableName() string { return "user_tag" }
 func (m UserTags) SQLTableName() string { return "user_tag" }
 
+var __sqlUserTag_Cols = []string{"province", "new_name"}
+
+func (_ *UserTag) SQLColumns() []string { return __sqlUserTag_Cols }
+func (_ UserTags) SQLColumns() []string { return __sqlUserTag_Cols }
+
 func (m *UserTag) SQLArgs(opts core.Opts, create bool) []interface{} {
 	return []interface{}{
 		core.String(m.Inline.Province),
@@ -1176,6 +1756,16 @@ func (m *UserTag) SQLScanArgs(opts core.Opts) []interface{} {
 	}
 }
 
+func (m *UserTag) SQLColumnValue(opts core.Opts, col string) (interface{}, bool) {
+	switch col {
+	case "province":
+		return core.String(m.Inline.Province), true
+	case "new_name":
+		return core.String(m.Rename), true
+	}
+	return nil, false
+}
+
 func (m *UserTag) SQLScan(opts core.Opts, row *sql.Row) error {
 	return row.Scan(m.SQLScanArgs(opts)...)
 }
@@ -1228,6 +1818,36 @@ func (ms UserTags) SQLInsert(w SQLWriter) error {
 	return nil
 }
 
+func (m *UserTag) SQLUpsert(w SQLWriter, conflictCols []string, updateCols []string) error {
+	if len(conflictCols) == 0 {
+		return core.Errorf("sqlgen: upsert UserTag without primary key requires OnConflict columns")
+	}
+	if err := m.SQLInsert(w); err != nil {
+		return err
+	}
+	w.WriteOnConflict(conflictCols, updateCols)
+	return nil
+}
+
+func (ms UserTags) SQLUpsert(w SQLWriter, conflictCols []string, updateCols []string) error {
+	if len(conflictCols) == 0 {
+		return core.Errorf("sqlgen: upsert UserTag without primary key requires OnConflict columns")
+	}
+	if err := ms.SQLInsert(w); err != nil {
+		return err
+	}
+	w.WriteOnConflict(conflictCols, updateCols)
+	return nil
+}
+
+func (_ *UserTag) SQLUpsertColumns(skipCreated bool) []string {
+	return []string{"province", "new_name"}
+}
+
+func (_ UserTags) SQLUpsertColumns(skipCreated bool) []string {
+	return (*UserTag)(nil).SQLUpsertColumns(skipCreated)
+}
+
 func (m *UserTag) SQLUpdate(w SQLWriter) error {
 	now, opts := time.Now(), w.Opts()
 	_, _ = now, opts // suppress unused error
@@ -1259,10 +1879,10 @@ func (m *UserTag) SQLUpdate(w SQLWriter) error {
 }
 
 func (m *UserTag) SQLUpdateAll(w SQLWriter) error {
-	w.WriteQueryString(__sqlUserTag_UpdateAll)
-	w.WriteRawString(" = (")
-	w.WriteMarkers(2)
-	w.WriteByte(')')
+	w.WriteRawString("UPDATE ")
+	w.WriteName("user_tag")
+	w.WriteRawString(" SET ")
+	w.WriteSetColumns(__sqlUserTag_Cols)
 	w.WriteArgs(m.SQLArgs(w.Opts(), false))
 	return nil
 }
@@ -1274,11 +1894,15 @@ const __sqlUserInline_ListCols = "\"province\",\"province\""
 const __sqlUserInline_Insert = "INSERT INTO \"user_inline\" (" + __sqlUserInline_ListCols + ") VALUES"
 const __sqlUserInline_Select = "SELECT " + __sqlUserInline_ListCols + " FROM \"user_inline\""
 const __sqlUserInline_Select_history = "SELECT " + __sqlUserInline_ListCols + " FROM history.\"user_inline\""
-const __sqlUserInline_UpdateAll = "UPDATE \"user_inline\" SET (" + __sqlUserInline_ListCols + ")"
 
 func (m *UserInline) SQLTableName() string { return "user_inline" }
 func (m UserInlines) SQLTableName() string { return "user_inline" }
 
+var __sqlUserInline_Cols = []string{"province", "province"}
+
+func (_ *UserInline) SQLColumns() []string { return __sqlUserInline_Cols }
+func (_ UserInlines) SQLColumns() []string { return __sqlUserInline_Cols }
+
 func (m *UserInline) SQLArgs(opts core.Opts, create bool) []interface{} {
 	return []interface{}{
 		core.String(m.Inline.Province),
@@ -1294,6 +1918,14 @@ func (m *UserInline) SQLScanArgs(opts core.Opts) []interface{} {
 	}
 }
 
+func (m *UserInline) SQLColumnValue(opts core.Opts, col string) (interface{}, bool) {
+	switch col {
+	case "province":
+		return core.String(m.Inline.Province), true
+	}
+	return nil, false
+}
+
 func (m *UserInline) SQLScan(opts core.Opts, row *sql.Row) error {
 	return row.Scan(m.SQLScanArgs(opts)...)
 }
@@ -1346,6 +1978,36 @@ func (ms UserInlines) SQLInsert(w SQLWriter) error {
 	return nil
 }
 
+func (m *UserInline) SQLUpsert(w SQLWriter, conflictCols []string, updateCols []string) error {
+	if len(conflictCols) == 0 {
+		return core.Errorf("sqlgen: upsert UserInline without primary key requires OnConflict columns")
+	}
+	if err := m.SQLInsert(w); err != nil {
+		return err
+	}
+	w.WriteOnConflict(conflictCols, updateCols)
+	return nil
+}
+
+func (ms UserInlines) SQLUpsert(w SQLWriter, conflictCols []string, updateCols []string) error {
+	if len(conflictCols) == 0 {
+		return core.Errorf("sqlgen: upsert UserInline without primary key requires OnConflict columns")
+	}
+	if err := ms.SQLInsert(w); err != nil {
+		return err
+	}
+	w.WriteOnConflict(conflictCols, updateCols)
+	return nil
+}
+
+func (_ *UserInline) SQLUpsertColumns(skipCreated bool) []string {
+	return []string{"province", "province"}
+}
+
+func (_ UserInlines) SQLUpsertColumns(skipCreated bool) []string {
+	return (*UserInline)(nil).SQLUpsertColumns(skipCreated)
+}
+
 func (m *UserInline) SQLUpdate(w SQLWriter) error {
 	now, opts := time.Now(), w.Opts()
 	_, _ = now, opts // suppress unused error
@@ -1377,10 +2039,1938 @@ func (m *UserInline) SQLUpdate(w SQLWriter) error {
 }
 
 func (m *UserInline) SQLUpdateAll(w SQLWriter) error {
-	w.WriteQueryString(__sqlUserInline_UpdateAll)
-	w.WriteRawString(" = (")
-	w.WriteMarkers(2)
-	w.WriteByte(')')
+	w.WriteRawString("UPDATE ")
+	w.WriteName("user_inline")
+	w.WriteRawString(" SET ")
+	w.WriteSetColumns(__sqlUserInline_Cols)
 	w.WriteArgs(m.SQLArgs(w.Opts(), false))
 	return nil
 }
+
+type Accounts []*Account
+
+const __sqlAccount_Table = "account"
+const __sqlAccount_ListCols = "\"id\",\"name\""
+const __sqlAccount_Insert = "INSERT INTO \"account\" (" + __sqlAccount_ListCols + ") VALUES"
+const __sqlAccount_Select = "SELECT " + __sqlAccount_ListCols + " FROM \"account\""
+const __sqlAccount_Select_history = "SELECT " + __sqlAccount_ListCols + " FROM history.\"account\""
+
+func (m *Account) SQLTableName() string { return "account" }
+func (m Accounts) SQLTableName() string { return "account" }
+
+var __sqlAccount_Cols = []string{"id", "name"}
+
+func (_ *Account) SQLColumns() []string { return __sqlAccount_Cols }
+func (_ Accounts) SQLColumns() []string { return __sqlAccount_Cols }
+
+func (m *Account) SQLArgs(opts core.Opts, create bool) []interface{} {
+	return []interface{}{
+		core.String(m.ID),
+		core.String(m.Name),
+	}
+}
+
+func (m *Account) SQLScanArgs(opts core.Opts) []interface{} {
+	return []interface{}{
+		(*core.String)(&m.ID),
+		(*core.String)(&m.Name),
+	}
+}
+
+func (m *Account) SQLColumnValue(opts core.Opts, col string) (interface{}, bool) {
+	switch col {
+	case "id":
+		return core.String(m.ID), true
+	case "name":
+		return core.String(m.Name), true
+	}
+	return nil, false
+}
+
+func (m *Account) SQLScan(opts core.Opts, row *sql.Row) error {
+	return row.Scan(m.SQLScanArgs(opts)...)
+}
+
+func (ms *Accounts) SQLScan(opts core.Opts, rows *sql.Rows) error {
+	res := make(Accounts, 0, 128)
+	for rows.Next() {
+		m := new(Account)
+		args := m.SQLScanArgs(opts)
+		if err := rows.Scan(args...); err != nil {
+			return err
+		}
+		res = append(res, m)
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	*ms = res
+	return nil
+}
+
+func (_ *Account) SQLSelect(w SQLWriter) error {
+	w.WriteQueryString(__sqlAccount_Select)
+	return nil
+}
+
+func (_ Accounts) SQLSelect(w SQLWriter) error {
+	w.WriteQueryString(__sqlAccount_Select)
+	return nil
+}
+
+func (m *Account) SQLInsert(w SQLWriter) error {
+	w.WriteQueryString(__sqlAccount_Insert)
+	w.WriteRawString(" (")
+	w.WriteMarkers(2)
+	w.WriteByte(')')
+	w.WriteArgs(m.SQLArgs(w.Opts(), true))
+	return nil
+}
+
+func (ms Accounts) SQLInsert(w SQLWriter) error {
+	w.WriteQueryString(__sqlAccount_Insert)
+	w.WriteRawString(" (")
+	for i := 0; i < len(ms); i++ {
+		w.WriteMarkers(2)
+		w.WriteArgs(ms[i].SQLArgs(w.Opts(), true))
+		w.WriteRawString("),(")
+	}
+	w.TrimLast(2)
+	return nil
+}
+
+func (m *Account) SQLUpsert(w SQLWriter, conflictCols []string, updateCols []string) error {
+	if len(conflictCols) == 0 {
+		conflictCols = __sqlAccount_PK
+	}
+	if err := m.SQLInsert(w); err != nil {
+		return err
+	}
+	w.WriteOnConflict(conflictCols, updateCols)
+	return nil
+}
+
+func (ms Accounts) SQLUpsert(w SQLWriter, conflictCols []string, updateCols []string) error {
+	if len(conflictCols) == 0 {
+		conflictCols = __sqlAccount_PK
+	}
+	if err := ms.SQLInsert(w); err != nil {
+		return err
+	}
+	w.WriteOnConflict(conflictCols, updateCols)
+	return nil
+}
+
+func (_ *Account) SQLUpsertColumns(skipCreated bool) []string {
+	return []string{"name"}
+}
+
+func (_ Accounts) SQLUpsertColumns(skipCreated bool) []string {
+	return (*Account)(nil).SQLUpsertColumns(skipCreated)
+}
+
+func (m *Account) SQLUpdate(w SQLWriter) error {
+	now, opts := time.Now(), w.Opts()
+	_, _ = now, opts // suppress unused error
+	var flag bool
+	w.WriteRawString("UPDATE ")
+	w.WriteName("account")
+	w.WriteRawString(" SET ")
+	if m.ID != "" {
+		flag = true
+		w.WriteName("id")
+		w.WriteByte('=')
+		w.WriteMarker()
+		w.WriteByte(',')
+		w.WriteArg(m.ID)
+	}
+	if m.Name != "" {
+		flag = true
+		w.WriteName("name")
+		w.WriteByte('=')
+		w.WriteMarker()
+		w.WriteByte(',')
+		w.WriteArg(m.Name)
+	}
+	if !flag {
+		return core.ErrNoColumn
+	}
+	w.TrimLast(1)
+	return nil
+}
+
+func (m *Account) SQLUpdateAll(w SQLWriter) error {
+	w.WriteRawString("UPDATE ")
+	w.WriteName("account")
+	w.WriteRawString(" SET ")
+	w.WriteSetColumns(__sqlAccount_Cols)
+	w.WriteArgs(m.SQLArgs(w.Opts(), false))
+	return nil
+}
+
+var __sqlAccount_PK = []string{"id"}
+
+type AccountKey struct {
+	ID string
+}
+
+func (m *Account) SQLKey() AccountKey {
+	return AccountKey{
+		ID: m.ID,
+	}
+}
+
+func (_ *Account) SQLPrimaryKeyColumns() []string { return __sqlAccount_PK }
+
+func (m *Account) SQLPrimaryKey(w SQLWriter) error {
+	if !(m.ID != "") {
+		return core.InvalidArgumentError("missing id")
+	}
+	w.WriteName("id")
+	w.WriteRawString(" = ")
+	w.WriteMarker()
+	w.WriteArg(m.ID)
+	return nil
+}
+
+func (m *Account) GetByPK(q sq.CommonQuery, pk string) (bool, error) {
+	m.ID = pk
+	return q.Get(m, sq.WriterToFunc(m.SQLPrimaryKey))
+}
+
+func (m *Account) GetByKey(q sq.CommonQuery, key AccountKey) (bool, error) {
+	m.ID = key.ID
+	return q.Get(m, sq.WriterToFunc(m.SQLPrimaryKey))
+}
+
+func (ms *Accounts) FindByKeys(q sq.CommonQuery, keys ...AccountKey) error {
+	args := make([]interface{}, 0, len(keys)*1)
+	for _, key := range keys {
+		args = append(args, key.ID)
+	}
+	return q.Where(sq.Ins(__sqlAccount_PK, args...)).Find(ms)
+}
+
+func (m *Account) UpdateByPK(q sq.CommonQuery) (int64, error) {
+	return q.Where(sq.WriterToFunc(m.SQLPrimaryKey)).Update(m)
+}
+
+func (m *Account) DeleteByPK(q sq.CommonQuery) (int64, error) {
+	return q.Where(sq.WriterToFunc(m.SQLPrimaryKey)).Delete(m)
+}
+
+func (m *Account) SQLPreload(table string) *core.PreloadDesc {
+	switch table {
+	case "account_user":
+		var items AccountUsers
+		return &core.PreloadDesc{
+			Fkey:  "account_id",
+			IDs:   []interface{}{m.ID},
+			Items: &items,
+		}
+	case "invoice_v2":
+		var items Invoices
+		return &core.PreloadDesc{
+			Fkey:  "account_id",
+			IDs:   []interface{}{m.ID},
+			Items: &items,
+		}
+	default:
+		return nil
+	}
+}
+
+func (m Accounts) SQLPreload(table string) *core.PreloadDesc {
+	switch table {
+	case "account_user":
+		ids := make([]interface{}, 0, len(m)*1)
+		for _, item := range m {
+			ids = append(ids, item.ID)
+		}
+		var items AccountUsers
+		return &core.PreloadDesc{
+			Fkey:  "account_id",
+			IDs:   ids,
+			Items: &items,
+		}
+	case "invoice_v2":
+		ids := make([]interface{}, 0, len(m)*1)
+		for _, item := range m {
+			ids = append(ids, item.ID)
+		}
+		var items Invoices
+		return &core.PreloadDesc{
+			Fkey:  "account_id",
+			IDs:   ids,
+			Items: &items,
+		}
+	default:
+		return nil
+	}
+}
+
+func (m *Account) SQLPopulate(items core.IFind) error {
+	switch items := items.(type) {
+	case *AccountUsers:
+		m.Users = *items
+		return nil
+	case *Invoices:
+		m.Invoices = *items
+		return nil
+	default:
+		return core.Errorf("can not populate %T into %T", items, m)
+	}
+}
+
+func (m Accounts) SQLPopulate(items core.IFind) error {
+	mapKey := make(map[AccountKey]*Account)
+	for _, item := range m {
+		mapKey[item.SQLKey()] = item
+	}
+
+	switch items := items.(type) {
+	case *AccountUsers:
+		for _, item := range *items {
+			mitem := mapKey[AccountKey{ID: item.AccountID}]
+			if mitem == nil {
+				return core.Errorf("can not populate id %v", AccountKey{ID: item.AccountID})
+			}
+			mitem.Users = append(mitem.Users, item)
+		}
+		return nil
+	case *Invoices:
+		for _, item := range *items {
+			mitem := mapKey[AccountKey{ID: item.AccountID}]
+			if mitem == nil {
+				return core.Errorf("can not populate id %v", AccountKey{ID: item.AccountID})
+			}
+			mitem.Invoices = append(mitem.Invoices, item)
+		}
+		return nil
+	default:
+		return core.Errorf("can not populate %T into %T", items, m)
+	}
+}
+
+type AccountUsers []*AccountUser
+
+const __sqlAccountUser_Table = "account_user"
+const __sqlAccountUser_ListCols = "\"account_id\",\"user_id\",\"role\""
+const __sqlAccountUser_Insert = "INSERT INTO \"account_user\" (" + __sqlAccountUser_ListCols + ") VALUES"
+const __sqlAccountUser_Select = "SELECT " + __sqlAccountUser_ListCols + " FROM \"account_user\""
+const __sqlAccountUser_Select_history = "SELECT " + __sqlAccountUser_ListCols + " FROM history.\"account_user\""
+
+func (m *AccountUser) SQLTableName() string { return "account_user" }
+func (m AccountUsers) SQLTableName() string { return "account_user" }
+
+var __sqlAccountUser_Cols = []string{"account_id", "user_id", "role"}
+
+func (_ *AccountUser) SQLColumns() []string { return __sqlAccountUser_Cols }
+func (_ AccountUsers) SQLColumns() []string { return __sqlAccountUser_Cols }
+
+func (m *AccountUser) SQLArgs(opts core.Opts, create bool) []interface{} {
+	return []interface{}{
+		core.String(m.AccountID),
+		core.String(m.UserID),
+		core.String(m.Role),
+	}
+}
+
+func (m *AccountUser) SQLScanArgs(opts core.Opts) []interface{} {
+	return []interface{}{
+		(*core.String)(&m.AccountID),
+		(*core.String)(&m.UserID),
+		(*core.String)(&m.Role),
+	}
+}
+
+func (m *AccountUser) SQLColumnValue(opts core.Opts, col string) (interface{}, bool) {
+	switch col {
+	case "account_id":
+		return core.String(m.AccountID), true
+	case "user_id":
+		return core.String(m.UserID), true
+	case "role":
+		return core.String(m.Role), true
+	}
+	return nil, false
+}
+
+func (m *AccountUser) SQLScan(opts core.Opts, row *sql.Row) error {
+	return row.Scan(m.SQLScanArgs(opts)...)
+}
+
+func (ms *AccountUsers) SQLScan(opts core.Opts, rows *sql.Rows) error {
+	res := make(AccountUsers, 0, 128)
+	for rows.Next() {
+		m := new(AccountUser)
+		args := m.SQLScanArgs(opts)
+		if err := rows.Scan(args...); err != nil {
+			return err
+		}
+		res = append(res, m)
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	*ms = res
+	return nil
+}
+
+func (_ *AccountUser) SQLSelect(w SQLWriter) error {
+	w.WriteQueryString(__sqlAccountUser_Select)
+	return nil
+}
+
+func (_ AccountUsers) SQLSelect(w SQLWriter) error {
+	w.WriteQueryString(__sqlAccountUser_Select)
+	return nil
+}
+
+func (m *AccountUser) SQLInsert(w SQLWriter) error {
+	w.WriteQueryString(__sqlAccountUser_Insert)
+	w.WriteRawString(" (")
+	w.WriteMarkers(3)
+	w.WriteByte(')')
+	w.WriteArgs(m.SQLArgs(w.Opts(), true))
+	return nil
+}
+
+func (ms AccountUsers) SQLInsert(w SQLWriter) error {
+	w.WriteQueryString(__sqlAccountUser_Insert)
+	w.WriteRawString(" (")
+	for i := 0; i < len(ms); i++ {
+		w.WriteMarkers(3)
+		w.WriteArgs(ms[i].SQLArgs(w.Opts(), true))
+		w.WriteRawString("),(")
+	}
+	w.TrimLast(2)
+	return nil
+}
+
+func (m *AccountUser) SQLUpsert(w SQLWriter, conflictCols []string, updateCols []string) error {
+	if len(conflictCols) == 0 {
+		conflictCols = __sqlAccountUser_PK
+	}
+	if err := m.SQLInsert(w); err != nil {
+		return err
+	}
+	w.WriteOnConflict(conflictCols, updateCols)
+	return nil
+}
+
+func (ms AccountUsers) SQLUpsert(w SQLWriter, conflictCols []string, updateCols []string) error {
+	if len(conflictCols) == 0 {
+		conflictCols = __sqlAccountUser_PK
+	}
+	if err := ms.SQLInsert(w); err != nil {
+		return err
+	}
+	w.WriteOnConflict(conflictCols, updateCols)
+	return nil
+}
+
+func (_ *AccountUser) SQLUpsertColumns(skipCreated bool) []string {
+	return []string{"role"}
+}
+
+func (_ AccountUsers) SQLUpsertColumns(skipCreated bool) []string {
+	return (*AccountUser)(nil).SQLUpsertColumns(skipCreated)
+}
+
+func (m *AccountUser) SQLUpdate(w SQLWriter) error {
+	now, opts := time.Now(), w.Opts()
+	_, _ = now, opts // suppress unused error
+	var flag bool
+	w.WriteRawString("UPDATE ")
+	w.WriteName("account_user")
+	w.WriteRawString(" SET ")
+	if m.AccountID != "" {
+		flag = true
+		w.WriteName("account_id")
+		w.WriteByte('=')
+		w.WriteMarker()
+		w.WriteByte(',')
+		w.WriteArg(m.AccountID)
+	}
+	if m.UserID != "" {
+		flag = true
+		w.WriteName("user_id")
+		w.WriteByte('=')
+		w.WriteMarker()
+		w.WriteByte(',')
+		w.WriteArg(m.UserID)
+	}
+	if m.Role != "" {
+		flag = true
+		w.WriteName("role")
+		w.WriteByte('=')
+		w.WriteMarker()
+		w.WriteByte(',')
+		w.WriteArg(m.Role)
+	}
+	if !flag {
+		return core.ErrNoColumn
+	}
+	w.TrimLast(1)
+	return nil
+}
+
+func (m *AccountUser) SQLUpdateAll(w SQLWriter) error {
+	w.WriteRawString("UPDATE ")
+	w.WriteName("account_user")
+	w.WriteRawString(" SET ")
+	w.WriteSetColumns(__sqlAccountUser_Cols)
+	w.WriteArgs(m.SQLArgs(w.Opts(), false))
+	return nil
+}
+
+var __sqlAccountUser_PK = []string{"account_id", "user_id"}
+
+type AccountUserKey struct {
+	AccountID string
+	UserID    string
+}
+
+func (m *AccountUser) SQLKey() AccountUserKey {
+	return AccountUserKey{
+		AccountID: m.AccountID,
+		UserID:    m.UserID,
+	}
+}
+
+func (_ *AccountUser) SQLPrimaryKeyColumns() []string { return __sqlAccountUser_PK }
+
+func (m *AccountUser) SQLPrimaryKey(w SQLWriter) error {
+	if !(m.AccountID != "") {
+		return core.InvalidArgumentError("missing account_id")
+	}
+	if !(m.UserID != "") {
+		return core.InvalidArgumentError("missing user_id")
+	}
+	w.WriteName("account_id")
+	w.WriteRawString(" = ")
+	w.WriteMarker()
+	w.WriteArg(m.AccountID)
+	w.WriteRawString(" AND ")
+	w.WriteName("user_id")
+	w.WriteRawString(" = ")
+	w.WriteMarker()
+	w.WriteArg(m.UserID)
+	return nil
+}
+
+func (m *AccountUser) GetByKey(q sq.CommonQuery, key AccountUserKey) (bool, error) {
+	m.AccountID = key.AccountID
+	m.UserID = key.UserID
+	return q.Get(m, sq.WriterToFunc(m.SQLPrimaryKey))
+}
+
+func (ms *AccountUsers) FindByKeys(q sq.CommonQuery, keys ...AccountUserKey) error {
+	args := make([]interface{}, 0, len(keys)*2)
+	for _, key := range keys {
+		args = append(args, key.AccountID, key.UserID)
+	}
+	return q.Where(sq.Ins(__sqlAccountUser_PK, args...)).Find(ms)
+}
+
+func (m *AccountUser) UpdateByPK(q sq.CommonQuery) (int64, error) {
+	return q.Where(sq.WriterToFunc(m.SQLPrimaryKey)).Update(m)
+}
+
+func (m *AccountUser) DeleteByPK(q sq.CommonQuery) (int64, error) {
+	return q.Where(sq.WriterToFunc(m.SQLPrimaryKey)).Delete(m)
+}
+
+func (m *AccountUser) SQLPreload(table string) *core.PreloadDesc {
+	switch table {
+	case "user":
+		var items Users
+		ids := make([]interface{}, 0, 1)
+		ids = append(ids, m.UserID)
+		return &core.PreloadDesc{
+			Fkey:  "id",
+			IDs:   ids,
+			Items: &items,
+		}
+	case "account_user_permission":
+		var items AccountUserPermissions
+		return &core.PreloadDesc{
+			Fkeys: []string{"account_id", "user_id"},
+			IDs:   []interface{}{m.AccountID, m.UserID},
+			Items: &items,
+		}
+	default:
+		return nil
+	}
+}
+
+func (m AccountUsers) SQLPreload(table string) *core.PreloadDesc {
+	switch table {
+	case "user":
+		ids := make([]interface{}, 0, len(m)*1)
+		for _, item := range m {
+			ids = append(ids, item.UserID)
+		}
+		var items Users
+		return &core.PreloadDesc{
+			Fkey:  "id",
+			IDs:   ids,
+			Items: &items,
+		}
+	case "account_user_permission":
+		ids := make([]interface{}, 0, len(m)*2)
+		for _, item := range m {
+			ids = append(ids, item.AccountID, item.UserID)
+		}
+		var items AccountUserPermissions
+		return &core.PreloadDesc{
+			Fkeys: []string{"account_id", "user_id"},
+			IDs:   ids,
+			Items: &items,
+		}
+	default:
+		return nil
+	}
+}
+
+func (m *AccountUser) SQLPopulate(items core.IFind) error {
+	switch items := items.(type) {
+	case *Users:
+		if len(*items) != 0 {
+			m.User = (*items)[0]
+		}
+		return nil
+	case *AccountUserPermissions:
+		m.Permissions = *items
+		return nil
+	default:
+		return core.Errorf("can not populate %T into %T", items, m)
+	}
+}
+
+func (m AccountUsers) SQLPopulate(items core.IFind) error {
+	mapKey := make(map[AccountUserKey]*AccountUser)
+	for _, item := range m {
+		mapKey[item.SQLKey()] = item
+	}
+
+	switch items := items.(type) {
+	case *Users:
+		mapItem := make(map[string]*User, len(*items))
+		for _, item := range *items {
+			mapItem[item.ID] = item
+		}
+		for _, item := range m {
+			item.User = mapItem[item.UserID]
+		}
+		return nil
+	case *AccountUserPermissions:
+		for _, item := range *items {
+			mitem := mapKey[AccountUserKey{AccountID: item.AccountID, UserID: item.UserID}]
+			if mitem == nil {
+				return core.Errorf("can not populate id %v", AccountUserKey{AccountID: item.AccountID, UserID: item.UserID})
+			}
+			mitem.Permissions = append(mitem.Permissions, item)
+		}
+		return nil
+	default:
+		return core.Errorf("can not populate %T into %T", items, m)
+	}
+}
+
+type AccountUserPermissions []*AccountUserPermission
+
+const __sqlAccountUserPermission_Table = "account_user_permission"
+const __sqlAccountUserPermission_ListCols = "\"account_id\",\"user_id\",\"permission\""
+const __sqlAccountUserPermission_Insert = "INSERT INTO \"account_user_permission\" (" + __sqlAccountUserPermission_ListCols + ") VALUES"
+const __sqlAccountUserPermission_Select = "SELECT " + __sqlAccountUserPermission_ListCols + " FROM \"account_user_permission\""
+const __sqlAccountUserPermission_Select_history = "SELECT " + __sqlAccountUserPermission_ListCols + " FROM history.\"account_user_permission\""
+
+func (m *AccountUserPermission) SQLTableName() string { return "account_user_permission" }
+func (m AccountUserPermissions) SQLTableName() string { return "account_user_permission" }
+
+var __sqlAccountUserPermission_Cols = []string{"account_id", "user_id", "permission"}
+
+func (_ *AccountUserPermission) SQLColumns() []string { return __sqlAccountUserPermission_Cols }
+func (_ AccountUserPermissions) SQLColumns() []string { return __sqlAccountUserPermission_Cols }
+
+func (m *AccountUserPermission) SQLArgs(opts core.Opts, create bool) []interface{} {
+	return []interface{}{
+		core.String(m.AccountID),
+		core.String(m.UserID),
+		core.String(m.Permission),
+	}
+}
+
+func (m *AccountUserPermission) SQLScanArgs(opts core.Opts) []interface{} {
+	return []interface{}{
+		(*core.String)(&m.AccountID),
+		(*core.String)(&m.UserID),
+		(*core.String)(&m.Permission),
+	}
+}
+
+func (m *AccountUserPermission) SQLColumnValue(opts core.Opts, col string) (interface{}, bool) {
+	switch col {
+	case "account_id":
+		return core.String(m.AccountID), true
+	case "user_id":
+		return core.String(m.UserID), true
+	case "permission":
+		return core.String(m.Permission), true
+	}
+	return nil, false
+}
+
+func (m *AccountUserPermission) SQLScan(opts core.Opts, row *sql.Row) error {
+	return row.Scan(m.SQLScanArgs(opts)...)
+}
+
+func (ms *AccountUserPermissions) SQLScan(opts core.Opts, rows *sql.Rows) error {
+	res := make(AccountUserPermissions, 0, 128)
+	for rows.Next() {
+		m := new(AccountUserPermission)
+		args := m.SQLScanArgs(opts)
+		if err := rows.Scan(args...); err != nil {
+			return err
+		}
+		res = append(res, m)
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	*ms = res
+	return nil
+}
+
+func (_ *AccountUserPermission) SQLSelect(w SQLWriter) error {
+	w.WriteQueryString(__sqlAccountUserPermission_Select)
+	return nil
+}
+
+func (_ AccountUserPermissions) SQLSelect(w SQLWriter) error {
+	w.WriteQueryString(__sqlAccountUserPermission_Select)
+	return nil
+}
+
+func (m *AccountUserPermission) SQLInsert(w SQLWriter) error {
+	w.WriteQueryString(__sqlAccountUserPermission_Insert)
+	w.WriteRawString(" (")
+	w.WriteMarkers(3)
+	w.WriteByte(')')
+	w.WriteArgs(m.SQLArgs(w.Opts(), true))
+	return nil
+}
+
+func (ms AccountUserPermissions) SQLInsert(w SQLWriter) error {
+	w.WriteQueryString(__sqlAccountUserPermission_Insert)
+	w.WriteRawString(" (")
+	for i := 0; i < len(ms); i++ {
+		w.WriteMarkers(3)
+		w.WriteArgs(ms[i].SQLArgs(w.Opts(), true))
+		w.WriteRawString("),(")
+	}
+	w.TrimLast(2)
+	return nil
+}
+
+func (m *AccountUserPermission) SQLUpsert(w SQLWriter, conflictCols []string, updateCols []string) error {
+	if len(conflictCols) == 0 {
+		return core.Errorf("sqlgen: upsert AccountUserPermission without primary key requires OnConflict columns")
+	}
+	if err := m.SQLInsert(w); err != nil {
+		return err
+	}
+	w.WriteOnConflict(conflictCols, updateCols)
+	return nil
+}
+
+func (ms AccountUserPermissions) SQLUpsert(w SQLWriter, conflictCols []string, updateCols []string) error {
+	if len(conflictCols) == 0 {
+		return core.Errorf("sqlgen: upsert AccountUserPermission without primary key requires OnConflict columns")
+	}
+	if err := ms.SQLInsert(w); err != nil {
+		return err
+	}
+	w.WriteOnConflict(conflictCols, updateCols)
+	return nil
+}
+
+func (_ *AccountUserPermission) SQLUpsertColumns(skipCreated bool) []string {
+	return []string{"account_id", "user_id", "permission"}
+}
+
+func (_ AccountUserPermissions) SQLUpsertColumns(skipCreated bool) []string {
+	return (*AccountUserPermission)(nil).SQLUpsertColumns(skipCreated)
+}
+
+func (m *AccountUserPermission) SQLUpdate(w SQLWriter) error {
+	now, opts := time.Now(), w.Opts()
+	_, _ = now, opts // suppress unused error
+	var flag bool
+	w.WriteRawString("UPDATE ")
+	w.WriteName("account_user_permission")
+	w.WriteRawString(" SET ")
+	if m.AccountID != "" {
+		flag = true
+		w.WriteName("account_id")
+		w.WriteByte('=')
+		w.WriteMarker()
+		w.WriteByte(',')
+		w.WriteArg(m.AccountID)
+	}
+	if m.UserID != "" {
+		flag = true
+		w.WriteName("user_id")
+		w.WriteByte('=')
+		w.WriteMarker()
+		w.WriteByte(',')
+		w.WriteArg(m.UserID)
+	}
+	if m.Permission != "" {
+		flag = true
+		w.WriteName("permission")
+		w.WriteByte('=')
+		w.WriteMarker()
+		w.WriteByte(',')
+		w.WriteArg(m.Permission)
+	}
+	if !flag {
+		return core.ErrNoColumn
+	}
+	w.TrimLast(1)
+	return nil
+}
+
+func (m *AccountUserPermission) SQLUpdateAll(w SQLWriter) error {
+	w.WriteRawString("UPDATE ")
+	w.WriteName("account_user_permission")
+	w.WriteRawString(" SET ")
+	w.WriteSetColumns(__sqlAccountUserPermission_Cols)
+	w.WriteArgs(m.SQLArgs(w.Opts(), false))
+	return nil
+}
+
+type Invoices []*Invoice
+
+const __sqlInvoice_Table = "invoice_v2"
+const __sqlInvoice_ListCols = "\"id\",\"account_id\",\"amount\",\"approved_by\""
+const __sqlInvoice_Insert = "INSERT INTO billing.\"invoice_v2\" (" + __sqlInvoice_ListCols + ") VALUES"
+const __sqlInvoice_Select = "SELECT " + __sqlInvoice_ListCols + " FROM billing.\"invoice_v2\""
+const __sqlInvoice_Select_history = "SELECT " + __sqlInvoice_ListCols + " FROM history.\"invoice_v2\""
+
+func (m *Invoice) SQLTableName() string { return "invoice_v2" }
+func (m Invoices) SQLTableName() string { return "invoice_v2" }
+
+var __sqlInvoice_Cols = []string{"id", "account_id", "amount", "approved_by"}
+
+func (_ *Invoice) SQLColumns() []string { return __sqlInvoice_Cols }
+func (_ Invoices) SQLColumns() []string { return __sqlInvoice_Cols }
+
+func (m *Invoice) SQLSchemaName() string { return "billing" }
+func (m Invoices) SQLSchemaName() string { return "billing" }
+
+func (m *Invoice) SQLArgs(opts core.Opts, create bool) []interface{} {
+	return []interface{}{
+		core.String(m.ID),
+		core.String(m.AccountID),
+		core.Int64(m.Amount),
+		m.ApprovedBy,
+	}
+}
+
+func (m *Invoice) SQLScanArgs(opts core.Opts) []interface{} {
+	return []interface{}{
+		(*core.String)(&m.ID),
+		(*core.String)(&m.AccountID),
+		(*core.Int64)(&m.Amount),
+		&m.ApprovedBy,
+	}
+}
+
+func (m *Invoice) SQLColumnValue(opts core.Opts, col string) (interface{}, bool) {
+	switch col {
+	case "id":
+		return core.String(m.ID), true
+	case "account_id":
+		return core.String(m.AccountID), true
+	case "amount":
+		return core.Int64(m.Amount), true
+	case "approved_by":
+		return m.ApprovedBy, true
+	}
+	return nil, false
+}
+
+func (m *Invoice) SQLScan(opts core.Opts, row *sql.Row) error {
+	return row.Scan(m.SQLScanArgs(opts)...)
+}
+
+func (ms *Invoices) SQLScan(opts core.Opts, rows *sql.Rows) error {
+	res := make(Invoices, 0, 128)
+	for rows.Next() {
+		m := new(Invoice)
+		args := m.SQLScanArgs(opts)
+		if err := rows.Scan(args...); err != nil {
+			return err
+		}
+		res = append(res, m)
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	*ms = res
+	return nil
+}
+
+func (_ *Invoice) SQLSelect(w SQLWriter) error {
+	w.WriteQueryString(__sqlInvoice_Select)
+	return nil
+}
+
+func (_ Invoices) SQLSelect(w SQLWriter) error {
+	w.WriteQueryString(__sqlInvoice_Select)
+	return nil
+}
+
+func (m *Invoice) SQLInsert(w SQLWriter) error {
+	w.WriteQueryString(__sqlInvoice_Insert)
+	w.WriteRawString(" (")
+	w.WriteMarkers(4)
+	w.WriteByte(')')
+	w.WriteArgs(m.SQLArgs(w.Opts(), true))
+	return nil
+}
+
+func (ms Invoices) SQLInsert(w SQLWriter) error {
+	w.WriteQueryString(__sqlInvoice_Insert)
+	w.WriteRawString(" (")
+	for i := 0; i < len(ms); i++ {
+		w.WriteMarkers(4)
+		w.WriteArgs(ms[i].SQLArgs(w.Opts(), true))
+		w.WriteRawString("),(")
+	}
+	w.TrimLast(2)
+	return nil
+}
+
+func (m *Invoice) SQLUpsert(w SQLWriter, conflictCols []string, updateCols []string) error {
+	if len(conflictCols) == 0 {
+		conflictCols = __sqlInvoice_PK
+	}
+	if err := m.SQLInsert(w); err != nil {
+		return err
+	}
+	w.WriteOnConflict(conflictCols, updateCols)
+	return nil
+}
+
+func (ms Invoices) SQLUpsert(w SQLWriter, conflictCols []string, updateCols []string) error {
+	if len(conflictCols) == 0 {
+		conflictCols = __sqlInvoice_PK
+	}
+	if err := ms.SQLInsert(w); err != nil {
+		return err
+	}
+	w.WriteOnConflict(conflictCols, updateCols)
+	return nil
+}
+
+func (_ *Invoice) SQLUpsertColumns(skipCreated bool) []string {
+	return []string{"account_id", "amount", "approved_by"}
+}
+
+func (_ Invoices) SQLUpsertColumns(skipCreated bool) []string {
+	return (*Invoice)(nil).SQLUpsertColumns(skipCreated)
+}
+
+func (m *Invoice) SQLUpdate(w SQLWriter) error {
+	now, opts := time.Now(), w.Opts()
+	_, _ = now, opts // suppress unused error
+	var flag bool
+	w.WriteRawString("UPDATE ")
+	w.WritePrefixedName("billing", "invoice_v2")
+	w.WriteRawString(" SET ")
+	if m.ID != "" {
+		flag = true
+		w.WriteName("id")
+		w.WriteByte('=')
+		w.WriteMarker()
+		w.WriteByte(',')
+		w.WriteArg(m.ID)
+	}
+	if m.AccountID != "" {
+		flag = true
+		w.WriteName("account_id")
+		w.WriteByte('=')
+		w.WriteMarker()
+		w.WriteByte(',')
+		w.WriteArg(m.AccountID)
+	}
+	if m.Amount != 0 {
+		flag = true
+		w.WriteName("amount")
+		w.WriteByte('=')
+		w.WriteMarker()
+		w.WriteByte(',')
+		w.WriteArg(m.Amount)
+	}
+	if m.ApprovedBy != nil {
+		flag = true
+		w.WriteName("approved_by")
+		w.WriteByte('=')
+		w.WriteMarker()
+		w.WriteByte(',')
+		w.WriteArg(*m.ApprovedBy)
+	}
+	if !flag {
+		return core.ErrNoColumn
+	}
+	w.TrimLast(1)
+	return nil
+}
+
+func (m *Invoice) SQLUpdateAll(w SQLWriter) error {
+	w.WriteRawString("UPDATE ")
+	w.WritePrefixedName("billing", "invoice_v2")
+	w.WriteRawString(" SET ")
+	w.WriteSetColumns(__sqlInvoice_Cols)
+	w.WriteArgs(m.SQLArgs(w.Opts(), false))
+	return nil
+}
+
+var __sqlInvoice_PK = []string{"id"}
+
+type InvoiceKey struct {
+	ID string
+}
+
+func (m *Invoice) SQLKey() InvoiceKey {
+	return InvoiceKey{
+		ID: m.ID,
+	}
+}
+
+func (_ *Invoice) SQLPrimaryKeyColumns() []string { return __sqlInvoice_PK }
+
+func (m *Invoice) SQLPrimaryKey(w SQLWriter) error {
+	if !(m.ID != "") {
+		return core.InvalidArgumentError("missing id")
+	}
+	w.WriteName("id")
+	w.WriteRawString(" = ")
+	w.WriteMarker()
+	w.WriteArg(m.ID)
+	return nil
+}
+
+func (m *Invoice) GetByPK(q sq.CommonQuery, pk string) (bool, error) {
+	m.ID = pk
+	return q.Get(m, sq.WriterToFunc(m.SQLPrimaryKey))
+}
+
+func (m *Invoice) GetByKey(q sq.CommonQuery, key InvoiceKey) (bool, error) {
+	m.ID = key.ID
+	return q.Get(m, sq.WriterToFunc(m.SQLPrimaryKey))
+}
+
+func (ms *Invoices) FindByKeys(q sq.CommonQuery, keys ...InvoiceKey) error {
+	args := make([]interface{}, 0, len(keys)*1)
+	for _, key := range keys {
+		args = append(args, key.ID)
+	}
+	return q.Where(sq.Ins(__sqlInvoice_PK, args...)).Find(ms)
+}
+
+func (m *Invoice) UpdateByPK(q sq.CommonQuery) (int64, error) {
+	return q.Where(sq.WriterToFunc(m.SQLPrimaryKey)).Update(m)
+}
+
+func (m *Invoice) DeleteByPK(q sq.CommonQuery) (int64, error) {
+	return q.Where(sq.WriterToFunc(m.SQLPrimaryKey)).Delete(m)
+}
+
+func (m *Invoice) SQLPreload(table string) *core.PreloadDesc {
+	switch table {
+	case "account":
+		var items Accounts
+		ids := make([]interface{}, 0, 1)
+		ids = append(ids, m.AccountID)
+		return &core.PreloadDesc{
+			Fkey:  "id",
+			IDs:   ids,
+			Items: &items,
+		}
+	case "user":
+		var items Users
+		ids := make([]interface{}, 0, 1)
+		if m.ApprovedBy != nil {
+			ids = append(ids, *m.ApprovedBy)
+		}
+		return &core.PreloadDesc{
+			Fkey:  "id",
+			IDs:   ids,
+			Items: &items,
+		}
+	case "role":
+		return Invoices{m}.SQLPreload(table)
+	default:
+		return nil
+	}
+}
+
+func (m Invoices) SQLPreload(table string) *core.PreloadDesc {
+	switch table {
+	case "account":
+		ids := make([]interface{}, 0, len(m)*1)
+		for _, item := range m {
+			ids = append(ids, item.AccountID)
+		}
+		var items Accounts
+		return &core.PreloadDesc{
+			Fkey:  "id",
+			IDs:   ids,
+			Items: &items,
+		}
+	case "user":
+		ids := make([]interface{}, 0, len(m)*1)
+		for _, item := range m {
+			if item.ApprovedBy != nil {
+				ids = append(ids, *item.ApprovedBy)
+			}
+		}
+		var items Users
+		return &core.PreloadDesc{
+			Fkey:  "id",
+			IDs:   ids,
+			Items: &items,
+		}
+	case "role":
+		ids := make([]interface{}, 0, len(m))
+		for _, item := range m {
+			ids = append(ids, item.ID)
+		}
+		type link struct {
+			Fkey string
+			Ref  string
+		}
+		var links []link
+		var items Roles
+		return &core.PreloadDesc{
+			Fkey:    "invoice_id",
+			IDs:     ids,
+			Items:   &items,
+			Through: "billing.\"invoice_role\"",
+			Ref:     "role_id",
+			RefKey:  "id",
+			Link: func() []interface{} {
+				links = append(links, link{})
+				l := &links[len(links)-1]
+				return []interface{}{&l.Fkey, &l.Ref}
+			},
+			RefIDs: func() []interface{} {
+				refs := make([]interface{}, len(links))
+				for i, l := range links {
+					refs[i] = l.Ref
+				}
+				return refs
+			},
+			Populate: func() error {
+				mapKey := make(map[string]*Invoice, len(m))
+				for _, item := range m {
+					mapKey[item.ID] = item
+				}
+				mapItem := make(map[string]*Role, len(items))
+				for _, item := range items {
+					mapItem[item.ID] = item
+				}
+				for _, l := range links {
+					mitem, item := mapKey[l.Fkey], mapItem[l.Ref]
+					if mitem != nil && item != nil {
+						mitem.ApproverRoles = append(mitem.ApproverRoles, item)
+					}
+				}
+				return nil
+			},
+		}
+	default:
+		return nil
+	}
+}
+
+func (m *Invoice) SQLPopulate(items core.IFind) error {
+	switch items := items.(type) {
+	case *Accounts:
+		if len(*items) != 0 {
+			m.Account = (*items)[0]
+		}
+		return nil
+	case *Users:
+		if len(*items) != 0 {
+			m.Approver = (*items)[0]
+		}
+		return nil
+	default:
+		return core.Errorf("can not populate %T into %T", items, m)
+	}
+}
+
+func (m Invoices) SQLPopulate(items core.IFind) error {
+	switch items := items.(type) {
+	case *Accounts:
+		mapItem := make(map[string]*Account, len(*items))
+		for _, item := range *items {
+			mapItem[item.ID] = item
+		}
+		for _, item := range m {
+			item.Account = mapItem[item.AccountID]
+		}
+		return nil
+	case *Users:
+		mapItem := make(map[string]*User, len(*items))
+		for _, item := range *items {
+			mapItem[item.ID] = item
+		}
+		for _, item := range m {
+			if item.ApprovedBy != nil {
+				item.Approver = mapItem[*item.ApprovedBy]
+			}
+		}
+		return nil
+	default:
+		return core.Errorf("can not populate %T into %T", items, m)
+	}
+}
+
+// AddApproverRoles links Roles to m by inserting into "invoice_role".
+func (m *Invoice) AddApproverRoles(tx sq.Tx, items ...*Role) (int64, error) {
+	if len(items) == 0 {
+		return 0, nil
+	}
+	res, err := tx.SQL(sq.WriterToFunc(func(w SQLWriter) error {
+		w.WriteRawString("INSERT INTO ")
+		w.WritePrefixedName("billing", "invoice_role")
+		w.WriteRawString(" (")
+		w.WriteName("invoice_id")
+		w.WriteByte(',')
+		w.WriteName("role_id")
+		w.WriteRawString(") VALUES ")
+		for _, item := range items {
+			w.WriteByte('(')
+			w.WriteMarkers(2)
+			w.WriteArgs([]interface{}{m.ID, item.ID})
+			w.WriteRawString("),")
+		}
+		w.TrimLast(1)
+		return nil
+	})).Exec()
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
+
+// RemoveApproverRoles unlinks Roles from m by deleting from "invoice_role".
+func (m *Invoice) RemoveApproverRoles(tx sq.Tx, items ...*Role) (int64, error) {
+	if len(items) == 0 {
+		return 0, nil
+	}
+	refs := make([]interface{}, len(items))
+	for i, item := range items {
+		refs[i] = item.ID
+	}
+	res, err := tx.SQL(sq.WriterToFunc(func(w SQLWriter) error {
+		w.WriteRawString("DELETE FROM ")
+		w.WritePrefixedName("billing", "invoice_role")
+		return nil
+	})).In("invoice_id", m.ID).In("role_id", refs).Exec()
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
+
+type Roles []*Role
+
+const __sqlRole_Table = "role"
+const __sqlRole_ListCols = "\"id\",\"name\""
+const __sqlRole_Insert = "INSERT INTO \"role\" (" + __sqlRole_ListCols + ") VALUES"
+const __sqlRole_Select = "SELECT " + __sqlRole_ListCols + " FROM \"role\""
+const __sqlRole_Select_history = "SELECT " + __sqlRole_ListCols + " FROM history.\"role\""
+
+func (m *Role) SQLTableName() string { return "role" }
+func (m Roles) SQLTableName() string { return "role" }
+
+var __sqlRole_Cols = []string{"id", "name"}
+
+func (_ *Role) SQLColumns() []string { return __sqlRole_Cols }
+func (_ Roles) SQLColumns() []string { return __sqlRole_Cols }
+
+func (m *Role) SQLArgs(opts core.Opts, create bool) []interface{} {
+	return []interface{}{
+		core.String(m.ID),
+		core.String(m.Name),
+	}
+}
+
+func (m *Role) SQLScanArgs(opts core.Opts) []interface{} {
+	return []interface{}{
+		(*core.String)(&m.ID),
+		(*core.String)(&m.Name),
+	}
+}
+
+func (m *Role) SQLColumnValue(opts core.Opts, col string) (interface{}, bool) {
+	switch col {
+	case "id":
+		return core.String(m.ID), true
+	case "name":
+		return core.String(m.Name), true
+	}
+	return nil, false
+}
+
+func (m *Role) SQLScan(opts core.Opts, row *sql.Row) error {
+	return row.Scan(m.SQLScanArgs(opts)...)
+}
+
+func (ms *Roles) SQLScan(opts core.Opts, rows *sql.Rows) error {
+	res := make(Roles, 0, 128)
+	for rows.Next() {
+		m := new(Role)
+		args := m.SQLScanArgs(opts)
+		if err := rows.Scan(args...); err != nil {
+			return err
+		}
+		res = append(res, m)
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	*ms = res
+	return nil
+}
+
+func (_ *Role) SQLSelect(w SQLWriter) error {
+	w.WriteQueryString(__sqlRole_Select)
+	return nil
+}
+
+func (_ Roles) SQLSelect(w SQLWriter) error {
+	w.WriteQueryString(__sqlRole_Select)
+	return nil
+}
+
+func (m *Role) SQLInsert(w SQLWriter) error {
+	w.WriteQueryString(__sqlRole_Insert)
+	w.WriteRawString(" (")
+	w.WriteMarkers(2)
+	w.WriteByte(')')
+	w.WriteArgs(m.SQLArgs(w.Opts(), true))
+	return nil
+}
+
+func (ms Roles) SQLInsert(w SQLWriter) error {
+	w.WriteQueryString(__sqlRole_Insert)
+	w.WriteRawString(" (")
+	for i := 0; i < len(ms); i++ {
+		w.WriteMarkers(2)
+		w.WriteArgs(ms[i].SQLArgs(w.Opts(), true))
+		w.WriteRawString("),(")
+	}
+	w.TrimLast(2)
+	return nil
+}
+
+func (m *Role) SQLUpsert(w SQLWriter, conflictCols []string, updateCols []string) error {
+	if len(conflictCols) == 0 {
+		conflictCols = __sqlRole_PK
+	}
+	if err := m.SQLInsert(w); err != nil {
+		return err
+	}
+	w.WriteOnConflict(conflictCols, updateCols)
+	return nil
+}
+
+func (ms Roles) SQLUpsert(w SQLWriter, conflictCols []string, updateCols []string) error {
+	if len(conflictCols) == 0 {
+		conflictCols = __sqlRole_PK
+	}
+	if err := ms.SQLInsert(w); err != nil {
+		return err
+	}
+	w.WriteOnConflict(conflictCols, updateCols)
+	return nil
+}
+
+func (_ *Role) SQLUpsertColumns(skipCreated bool) []string {
+	return []string{"name"}
+}
+
+func (_ Roles) SQLUpsertColumns(skipCreated bool) []string {
+	return (*Role)(nil).SQLUpsertColumns(skipCreated)
+}
+
+func (m *Role) SQLUpdate(w SQLWriter) error {
+	now, opts := time.Now(), w.Opts()
+	_, _ = now, opts // suppress unused error
+	var flag bool
+	w.WriteRawString("UPDATE ")
+	w.WriteName("role")
+	w.WriteRawString(" SET ")
+	if m.ID != "" {
+		flag = true
+		w.WriteName("id")
+		w.WriteByte('=')
+		w.WriteMarker()
+		w.WriteByte(',')
+		w.WriteArg(m.ID)
+	}
+	if m.Name != "" {
+		flag = true
+		w.WriteName("name")
+		w.WriteByte('=')
+		w.WriteMarker()
+		w.WriteByte(',')
+		w.WriteArg(m.Name)
+	}
+	if !flag {
+		return core.ErrNoColumn
+	}
+	w.TrimLast(1)
+	return nil
+}
+
+func (m *Role) SQLUpdateAll(w SQLWriter) error {
+	w.WriteRawString("UPDATE ")
+	w.WriteName("role")
+	w.WriteRawString(" SET ")
+	w.WriteSetColumns(__sqlRole_Cols)
+	w.WriteArgs(m.SQLArgs(w.Opts(), false))
+	return nil
+}
+
+var __sqlRole_PK = []string{"id"}
+
+type RoleKey struct {
+	ID string
+}
+
+func (m *Role) SQLKey() RoleKey {
+	return RoleKey{
+		ID: m.ID,
+	}
+}
+
+func (_ *Role) SQLPrimaryKeyColumns() []string { return __sqlRole_PK }
+
+func (m *Role) SQLPrimaryKey(w SQLWriter) error {
+	if !(m.ID != "") {
+		return core.InvalidArgumentError("missing id")
+	}
+	w.WriteName("id")
+	w.WriteRawString(" = ")
+	w.WriteMarker()
+	w.WriteArg(m.ID)
+	return nil
+}
+
+func (m *Role) GetByPK(q sq.CommonQuery, pk string) (bool, error) {
+	m.ID = pk
+	return q.Get(m, sq.WriterToFunc(m.SQLPrimaryKey))
+}
+
+func (m *Role) GetByKey(q sq.CommonQuery, key RoleKey) (bool, error) {
+	m.ID = key.ID
+	return q.Get(m, sq.WriterToFunc(m.SQLPrimaryKey))
+}
+
+func (ms *Roles) FindByKeys(q sq.CommonQuery, keys ...RoleKey) error {
+	args := make([]interface{}, 0, len(keys)*1)
+	for _, key := range keys {
+		args = append(args, key.ID)
+	}
+	return q.Where(sq.Ins(__sqlRole_PK, args...)).Find(ms)
+}
+
+func (m *Role) UpdateByPK(q sq.CommonQuery) (int64, error) {
+	return q.Where(sq.WriterToFunc(m.SQLPrimaryKey)).Update(m)
+}
+
+func (m *Role) DeleteByPK(q sq.CommonQuery) (int64, error) {
+	return q.Where(sq.WriterToFunc(m.SQLPrimaryKey)).Delete(m)
+}
+
+type Settings []*Setting
+
+const __sqlSetting_Table = "setting"
+const __sqlSetting_ListCols = "\"key\",\"value\",\"created_at\",\"updated_at\""
+const __sqlSetting_Insert = "INSERT INTO \"setting\" (" + __sqlSetting_ListCols + ") VALUES"
+const __sqlSetting_Select = "SELECT " + __sqlSetting_ListCols + " FROM \"setting\""
+const __sqlSetting_Select_history = "SELECT " + __sqlSetting_ListCols + " FROM history.\"setting\""
+
+func (m *Setting) SQLTableName() string { return "setting" }
+func (m Settings) SQLTableName() string { return "setting" }
+
+var __sqlSetting_Cols = []string{"key", "value", "created_at", "updated_at"}
+
+func (_ *Setting) SQLColumns() []string { return __sqlSetting_Cols }
+func (_ Settings) SQLColumns() []string { return __sqlSetting_Cols }
+
+func (m *Setting) SQLArgs(opts core.Opts, create bool) []interface{} {
+	now := time.Now()
+	return []interface{}{
+		core.String(m.Key),
+		core.String(m.Value),
+		core.Now(m.CreatedAt, now, create),
+		core.Now(m.UpdatedAt, now, true),
+	}
+}
+
+func (m *Setting) SQLScanArgs(opts core.Opts) []interface{} {
+	return []interface{}{
+		(*core.String)(&m.Key),
+		(*core.String)(&m.Value),
+		(*core.Time)(&m.CreatedAt),
+		(*core.Time)(&m.UpdatedAt),
+	}
+}
+
+func (m *Setting) SQLColumnValue(opts core.Opts, col string) (interface{}, bool) {
+	switch col {
+	case "key":
+		return core.String(m.Key), true
+	case "value":
+		return core.String(m.Value), true
+	case "created_at":
+		return core.Time(m.CreatedAt), true
+	case "updated_at":
+		return core.Time(m.UpdatedAt), true
+	}
+	return nil, false
+}
+
+func (m *Setting) SQLScan(opts core.Opts, row *sql.Row) error {
+	return row.Scan(m.SQLScanArgs(opts)...)
+}
+
+func (ms *Settings) SQLScan(opts core.Opts, rows *sql.Rows) error {
+	res := make(Settings, 0, 128)
+	for rows.Next() {
+		m := new(Setting)
+		args := m.SQLScanArgs(opts)
+		if err := rows.Scan(args...); err != nil {
+			return err
+		}
+		res = append(res, m)
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	*ms = res
+	return nil
+}
+
+func (_ *Setting) SQLSelect(w SQLWriter) error {
+	w.WriteQueryString(__sqlSetting_Select)
+	return nil
+}
+
+func (_ Settings) SQLSelect(w SQLWriter) error {
+	w.WriteQueryString(__sqlSetting_Select)
+	return nil
+}
+
+func (m *Setting) SQLInsert(w SQLWriter) error {
+	w.WriteQueryString(__sqlSetting_Insert)
+	w.WriteRawString(" (")
+	w.WriteMarkers(4)
+	w.WriteByte(')')
+	w.WriteArgs(m.SQLArgs(w.Opts(), true))
+	return nil
+}
+
+func (ms Settings) SQLInsert(w SQLWriter) error {
+	w.WriteQueryString(__sqlSetting_Insert)
+	w.WriteRawString(" (")
+	for i := 0; i < len(ms); i++ {
+		w.WriteMarkers(4)
+		w.WriteArgs(ms[i].SQLArgs(w.Opts(), true))
+		w.WriteRawString("),(")
+	}
+	w.TrimLast(2)
+	return nil
+}
+
+func (m *Setting) SQLUpsert(w SQLWriter, conflictCols []string, updateCols []string) error {
+	if len(conflictCols) == 0 {
+		conflictCols = __sqlSetting_PK
+	}
+	if err := m.SQLInsert(w); err != nil {
+		return err
+	}
+	w.WriteOnConflict(conflictCols, updateCols)
+	return nil
+}
+
+func (ms Settings) SQLUpsert(w SQLWriter, conflictCols []string, updateCols []string) error {
+	if len(conflictCols) == 0 {
+		conflictCols = __sqlSetting_PK
+	}
+	if err := ms.SQLInsert(w); err != nil {
+		return err
+	}
+	w.WriteOnConflict(conflictCols, updateCols)
+	return nil
+}
+
+func (_ *Setting) SQLUpsertColumns(skipCreated bool) []string {
+	if skipCreated {
+		return []string{"value", "updated_at"}
+	}
+	return []string{"value", "created_at", "updated_at"}
+}
+
+func (_ Settings) SQLUpsertColumns(skipCreated bool) []string {
+	return (*Setting)(nil).SQLUpsertColumns(skipCreated)
+}
+
+func (m *Setting) SQLUpdate(w SQLWriter) error {
+	now, opts := time.Now(), w.Opts()
+	_, _ = now, opts // suppress unused error
+	var flag bool
+	w.WriteRawString("UPDATE ")
+	w.WriteName("setting")
+	w.WriteRawString(" SET ")
+	if m.Key != "" {
+		flag = true
+		w.WriteName("key")
+		w.WriteByte('=')
+		w.WriteMarker()
+		w.WriteByte(',')
+		w.WriteArg(m.Key)
+	}
+	if m.Value != "" {
+		flag = true
+		w.WriteName("value")
+		w.WriteByte('=')
+		w.WriteMarker()
+		w.WriteByte(',')
+		w.WriteArg(m.Value)
+	}
+	if !m.CreatedAt.IsZero() {
+		flag = true
+		w.WriteName("created_at")
+		w.WriteByte('=')
+		w.WriteMarker()
+		w.WriteByte(',')
+		w.WriteArg(m.CreatedAt)
+	}
+	if !m.UpdatedAt.IsZero() {
+		flag = true
+		w.WriteName("updated_at")
+		w.WriteByte('=')
+		w.WriteMarker()
+		w.WriteByte(',')
+		w.WriteArg(core.Now(m.UpdatedAt, time.Now(), true))
+	}
+	if !flag {
+		return core.ErrNoColumn
+	}
+	w.TrimLast(1)
+	return nil
+}
+
+func (m *Setting) SQLUpdateAll(w SQLWriter) error {
+	w.WriteRawString("UPDATE ")
+	w.WriteName("setting")
+	w.WriteRawString(" SET ")
+	w.WriteSetColumns(__sqlSetting_Cols)
+	w.WriteArgs(m.SQLArgs(w.Opts(), false))
+	return nil
+}
+
+var __sqlSetting_PK = []string{"key"}
+
+type SettingKey struct {
+	Key string
+}
+
+func (m *Setting) SQLKey() SettingKey {
+	return SettingKey{
+		Key: m.Key,
+	}
+}
+
+func (_ *Setting) SQLPrimaryKeyColumns() []string { return __sqlSetting_PK }
+
+func (m *Setting) SQLPrimaryKey(w SQLWriter) error {
+	if !(m.Key != "") {
+		return core.InvalidArgumentError("missing key")
+	}
+	w.WriteName("key")
+	w.WriteRawString(" = ")
+	w.WriteMarker()
+	w.WriteArg(m.Key)
+	return nil
+}
+
+func (m *Setting) GetByPK(q sq.CommonQuery, pk string) (bool, error) {
+	m.Key = pk
+	return q.Get(m, sq.WriterToFunc(m.SQLPrimaryKey))
+}
+
+func (m *Setting) GetByKey(q sq.CommonQuery, key SettingKey) (bool, error) {
+	m.Key = key.Key
+	return q.Get(m, sq.WriterToFunc(m.SQLPrimaryKey))
+}
+
+func (ms *Settings) FindByKeys(q sq.CommonQuery, keys ...SettingKey) error {
+	args := make([]interface{}, 0, len(keys)*1)
+	for _, key := range keys {
+		args = append(args, key.Key)
+	}
+	return q.Where(sq.Ins(__sqlSetting_PK, args...)).Find(ms)
+}
+
+func (m *Setting) UpdateByPK(q sq.CommonQuery) (int64, error) {
+	return q.Where(sq.WriterToFunc(m.SQLPrimaryKey)).Update(m)
+}
+
+func (m *Setting) DeleteByPK(q sq.CommonQuery) (int64, error) {
+	return q.Where(sq.WriterToFunc(m.SQLPrimaryKey)).Delete(m)
+}
+
+type Events []*Event
+
+const __sqlEvent_Table = "event"
+const __sqlEvent_ListCols = "\"id\",\"name\",\"created_at\""
+const __sqlEvent_Insert = "INSERT INTO \"event\" (" + __sqlEvent_ListCols + ") VALUES"
+const __sqlEvent_Select = "SELECT " + __sqlEvent_ListCols + " FROM \"event\""
+const __sqlEvent_Select_history = "SELECT " + __sqlEvent_ListCols + " FROM history.\"event\""
+
+func (m *Event) SQLTableName() string { return "event" }
+func (m Events) SQLTableName() string { return "event" }
+
+var __sqlEvent_Cols = []string{"id", "name", "created_at"}
+
+func (_ *Event) SQLColumns() []string { return __sqlEvent_Cols }
+func (_ Events) SQLColumns() []string { return __sqlEvent_Cols }
+
+func (m *Event) SQLArgs(opts core.Opts, create bool) []interface{} {
+	now := time.Now()
+	return []interface{}{
+		core.Int64(m.ID),
+		core.String(m.Name),
+		core.Now(m.CreatedAt, now, create),
+	}
+}
+
+func (m *Event) SQLScanArgs(opts core.Opts) []interface{} {
+	return []interface{}{
+		(*core.Int64)(&m.ID),
+		(*core.String)(&m.Name),
+		(*core.Time)(&m.CreatedAt),
+	}
+}
+
+func (m *Event) SQLColumnValue(opts core.Opts, col string) (interface{}, bool) {
+	switch col {
+	case "id":
+		return core.Int64(m.ID), true
+	case "name":
+		return core.String(m.Name), true
+	case "created_at":
+		return core.Time(m.CreatedAt), true
+	}
+	return nil, false
+}
+
+func (m *Event) SQLScan(opts core.Opts, row *sql.Row) error {
+	return row.Scan(m.SQLScanArgs(opts)...)
+}
+
+func (ms *Events) SQLScan(opts core.Opts, rows *sql.Rows) error {
+	res := make(Events, 0, 128)
+	for rows.Next() {
+		m := new(Event)
+		args := m.SQLScanArgs(opts)
+		if err := rows.Scan(args...); err != nil {
+			return err
+		}
+		res = append(res, m)
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	*ms = res
+	return nil
+}
+
+func (_ *Event) SQLSelect(w SQLWriter) error {
+	w.WriteQueryString(__sqlEvent_Select)
+	return nil
+}
+
+func (_ Events) SQLSelect(w SQLWriter) error {
+	w.WriteQueryString(__sqlEvent_Select)
+	return nil
+}
+
+func (m *Event) SQLInsert(w SQLWriter) error {
+	w.WriteQueryString(__sqlEvent_Insert)
+	w.WriteRawString(" (")
+	w.WriteMarkers(3)
+	w.WriteByte(')')
+	w.WriteArgs(m.SQLArgs(w.Opts(), true))
+	return nil
+}
+
+func (ms Events) SQLInsert(w SQLWriter) error {
+	w.WriteQueryString(__sqlEvent_Insert)
+	w.WriteRawString(" (")
+	for i := 0; i < len(ms); i++ {
+		w.WriteMarkers(3)
+		w.WriteArgs(ms[i].SQLArgs(w.Opts(), true))
+		w.WriteRawString("),(")
+	}
+	w.TrimLast(2)
+	return nil
+}
+
+func (m *Event) SQLUpsert(w SQLWriter, conflictCols []string, updateCols []string) error {
+	if len(conflictCols) == 0 {
+		conflictCols = __sqlEvent_PK
+	}
+	if err := m.SQLInsert(w); err != nil {
+		return err
+	}
+	w.WriteOnConflict(conflictCols, updateCols)
+	return nil
+}
+
+func (ms Events) SQLUpsert(w SQLWriter, conflictCols []string, updateCols []string) error {
+	if len(conflictCols) == 0 {
+		conflictCols = __sqlEvent_PK
+	}
+	if err := ms.SQLInsert(w); err != nil {
+		return err
+	}
+	w.WriteOnConflict(conflictCols, updateCols)
+	return nil
+}
+
+func (_ *Event) SQLUpsertColumns(skipCreated bool) []string {
+	if skipCreated {
+		return []string{"name"}
+	}
+	return []string{"name", "created_at"}
+}
+
+func (_ Events) SQLUpsertColumns(skipCreated bool) []string {
+	return (*Event)(nil).SQLUpsertColumns(skipCreated)
+}
+
+func (m *Event) SQLUpdate(w SQLWriter) error {
+	now, opts := time.Now(), w.Opts()
+	_, _ = now, opts // suppress unused error
+	var flag bool
+	w.WriteRawString("UPDATE ")
+	w.WriteName("event")
+	w.WriteRawString(" SET ")
+	if m.ID != 0 {
+		flag = true
+		w.WriteName("id")
+		w.WriteByte('=')
+		w.WriteMarker()
+		w.WriteByte(',')
+		w.WriteArg(m.ID)
+	}
+	if m.Name != "" {
+		flag = true
+		w.WriteName("name")
+		w.WriteByte('=')
+		w.WriteMarker()
+		w.WriteByte(',')
+		w.WriteArg(m.Name)
+	}
+	if !m.CreatedAt.IsZero() {
+		flag = true
+		w.WriteName("created_at")
+		w.WriteByte('=')
+		w.WriteMarker()
+		w.WriteByte(',')
+		w.WriteArg(m.CreatedAt)
+	}
+	if !flag {
+		return core.ErrNoColumn
+	}
+	w.TrimLast(1)
+	return nil
+}
+
+func (m *Event) SQLUpdateAll(w SQLWriter) error {
+	w.WriteRawString("UPDATE ")
+	w.WriteName("event")
+	w.WriteRawString(" SET ")
+	w.WriteSetColumns(__sqlEvent_Cols)
+	w.WriteArgs(m.SQLArgs(w.Opts(), false))
+	return nil
+}
+
+var __sqlEvent_PK = []string{"id"}
+
+type EventKey struct {
+	ID int64
+}
+
+func (m *Event) SQLKey() EventKey {
+	return EventKey{
+		ID: m.ID,
+	}
+}
+
+func (_ *Event) SQLPrimaryKeyColumns() []string { return __sqlEvent_PK }
+
+func (m *Event) SQLPrimaryKey(w SQLWriter) error {
+	if !(m.ID != 0) {
+		return core.InvalidArgumentError("missing id")
+	}
+	w.WriteName("id")
+	w.WriteRawString(" = ")
+	w.WriteMarker()
+	w.WriteArg(m.ID)
+	return nil
+}
+
+func (m *Event) SQLSetLastInsertID(id int64) {
+	m.ID = int64(id)
+}
+
+func (m *Event) GetByPK(q sq.CommonQuery, pk int64) (bool, error) {
+	m.ID = pk
+	return q.Get(m, sq.WriterToFunc(m.SQLPrimaryKey))
+}
+
+func (m *Event) GetByKey(q sq.CommonQuery, key EventKey) (bool, error) {
+	m.ID = key.ID
+	return q.Get(m, sq.WriterToFunc(m.SQLPrimaryKey))
+}
+
+func (ms *Events) FindByKeys(q sq.CommonQuery, keys ...EventKey) error {
+	args := make([]interface{}, 0, len(keys)*1)
+	for _, key := range keys {
+		args = append(args, key.ID)
+	}
+	return q.Where(sq.Ins(__sqlEvent_PK, args...)).Find(ms)
+}
+
+func (m *Event) UpdateByPK(q sq.CommonQuery) (int64, error) {
+	return q.Where(sq.WriterToFunc(m.SQLPrimaryKey)).Update(m)
+}
+
+func (m *Event) DeleteByPK(q sq.CommonQuery) (int64, error) {
+	return q.Where(sq.WriterToFunc(m.SQLPrimaryKey)).Delete(m)
+}
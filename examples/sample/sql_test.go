@@ -1,10 +1,15 @@
 package test
 
 import (
+	"context"
 	"database/sql"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"log"
+	"os"
 	"regexp"
+	"strings"
 	"testing"
 	"time"
 
@@ -12,6 +17,7 @@ import (
 	. "github.com/ng-vu/goconveyx"
 	. "github.com/smartystreets/goconvey/convey"
 
+	"github.com/ng-vu/sqlgen/core"
 	mock "github.com/ng-vu/sqlgen/mock"
 	sq "github.com/ng-vu/sqlgen/typesafe/sq"
 )
@@ -28,13 +34,24 @@ type (
 	M map[string]interface{}
 )
 
-func init() {
-	connStr := "port=15432 user=sqlgen password=sqlgen dbname=sqlgen sslmode=disable connect_timeout=10"
-	db = sq.MustConnect("postgres", connStr, sq.SetErrorMapper(merr.Mock))
-	db.MustExec("SELECT 1")
+const connStr = "port=15432 user=sqlgen password=sqlgen dbname=sqlgen sslmode=disable connect_timeout=10"
 
-	InitSchema()
-	log.Println("Initialized database for testing")
+func init() {
+	// SAMPLE_DB=mysql runs the tests on the MySQL of docker-compose
+	if os.Getenv("SAMPLE_DB") == "mysql" {
+		db = connectMySQL(sq.SetErrorMapper(merr.Mock))
+		InitMySQLSchema()
+		log.Println("Initialized MySQL database for testing")
+	} else {
+		db = sq.MustConnect("postgres", connStr, sq.SetErrorMapper(merr.Mock))
+		if _, err := db.Exec("SELECT 1"); err != nil {
+			log.Printf("Postgres is not available, running the tests on SQLite: %v", err)
+			db = connectSQLite(sq.SetErrorMapper(merr.Mock))
+		} else {
+			InitSchema()
+			log.Println("Initialized database for testing")
+		}
+	}
 
 	location, err := time.LoadLocation("Asia/Ho_Chi_Minh")
 	if err != nil {
@@ -44,9 +61,60 @@ func init() {
 	now1 = time.Date(2021, 10, 11, 8, 9, 10, 123e6, location)
 }
 
+// isPostgres reports whether the tests run on the Postgres of docker-compose.
+// Otherwise they run on MySQL or an in-memory SQLite database.
+func isPostgres() bool {
+	return db.Opts().Dialect.Name() == sq.Postgres.Name()
+}
+
+// connect connects to a database of the same kind as db.
+func connect(opts ...sq.Option) *sq.Database {
+	switch {
+	case isPostgres():
+		return sq.MustConnect("postgres", connStr, opts...)
+	case isMySQL():
+		return connectMySQL(opts...)
+	}
+	return connectSQLite(opts...)
+}
+
+// ConveyPostgres is Convey for tests which need the features of Postgres.
+func ConveyPostgres(name string, action func()) {
+	if isPostgres() {
+		Convey(name, action)
+	} else {
+		SkipConvey(name, action)
+	}
+}
+
+// rebind replaces the markers $1, $2, ... of an expected query with the
+// markers of the database under test, and the quotes with backticks on MySQL.
+func rebind(query string) string {
+	if isPostgres() {
+		return query
+	}
+	if isMySQL() {
+		query = strings.Replace(query, `"`, "`", -1)
+	}
+	return reMarker.ReplaceAllString(query, "?")
+}
+
+var reMarker = regexp.MustCompile(`\$[0-9]+`)
+
+// truncate deletes all rows of the tables.
+func truncate(tables ...string) {
+	for _, table := range tables {
+		db.MustExec(`DELETE FROM ` + table)
+	}
+}
+
 func InitSchema() {
 	db.MustExec(`
 		DROP TABLE IF EXISTS "user_info", "complex_info", "user";
+		DROP TABLE IF EXISTS "account", "account_user", "account_user_permission";
+		DROP TABLE IF EXISTS "role", "user_role", "setting";
+		DROP SCHEMA IF EXISTS billing CASCADE;
+		CREATE SCHEMA billing;
         CREATE TABLE "user" (
             id TEXT PRIMARY KEY,
             name       TEXT,
@@ -100,13 +168,54 @@ func InitSchema() {
 			alias_p_float64 DOUBLE PRECISION
 			-- alias_p_time    TIMESTAMPTZ
 		);
+		CREATE TABLE "account" (
+			id   TEXT PRIMARY KEY,
+			name TEXT
+		);
+		CREATE TABLE "account_user" (
+			account_id TEXT,
+			user_id    TEXT,
+			role       TEXT,
+			PRIMARY KEY (account_id, user_id)
+		);
+		CREATE TABLE "account_user_permission" (
+			account_id TEXT,
+			user_id    TEXT,
+			permission TEXT
+		);
+		CREATE TABLE "role" (
+			id   TEXT PRIMARY KEY,
+			name TEXT
+		);
+		CREATE TABLE "user_role" (
+			user_id TEXT,
+			role_id TEXT,
+			PRIMARY KEY (user_id, role_id)
+		);
+		CREATE TABLE "setting" (
+			key        TEXT PRIMARY KEY,
+			value      TEXT,
+			created_at TIMESTAMPTZ,
+			updated_at TIMESTAMPTZ
+		);
+		CREATE TABLE billing."invoice_v2" (
+			id         TEXT PRIMARY KEY,
+			account_id  TEXT,
+			amount      BIGINT,
+			approved_by TEXT
+		);
+		CREATE TABLE billing."invoice_role" (
+			invoice_id TEXT,
+			role_id    TEXT,
+			PRIMARY KEY (invoice_id, role_id)
+		);
 	`)
 }
 
 func TestUser(t *testing.T) {
 	Convey("Insert", t, func() {
 		Reset(func() {
-			db.MustExec(`TRUNCATE "user"`)
+			truncate(`"user"`)
 		})
 
 		users := []*User{
@@ -114,7 +223,7 @@ func TestUser(t *testing.T) {
 				ID:        "1000",
 				Name:      "Alice",
 				CreatedAt: now0,
-				UpdatedAt: &now1,
+				UpdatedAt: pTime(now1),
 				Bool:      true,
 				Float64:   1000.1,
 				Int:       1001,
@@ -191,7 +300,7 @@ func TestUser(t *testing.T) {
 			So(err, ShouldBeNil)
 			So(len(args), ShouldEqual, 28)
 
-			expectedQuery := `INSERT INTO "user" ("id","name","created_at","updated_at","bool","float64","int","int64","string","p_bool","p_float64","p_int","p_int64","p_string") VALUES ($1,$2,$3,$4,$5,$6,$7,$8,$9,$10,$11,$12,$13,$14),($15,$16,$17,$18,$19,$20,$21,$22,$23,$24,$25,$26,$27,$28)`
+			expectedQuery := rebind(`INSERT INTO "user" ("id","name","created_at","updated_at","bool","float64","int","int64","string","p_bool","p_float64","p_int","p_int64","p_string") VALUES ($1,$2,$3,$4,$5,$6,$7,$8,$9,$10,$11,$12,$13,$14),($15,$16,$17,$18,$19,$20,$21,$22,$23,$24,$25,$26,$27,$28)`)
 			So(query, ShouldEqual, expectedQuery)
 		})
 		Convey("Get again", func() {
@@ -206,6 +315,34 @@ func TestUser(t *testing.T) {
 			So(len(_users), ShouldEqual, 2)
 			So(_users, ShouldResembleSlice, users)
 		})
+		Convey("Iterate", func() {
+			ctx := context.Background()
+			var user User
+			var ids []string
+			err := db.OrderBy("id").Iterate(ctx, &user, func() error {
+				ids = append(ids, user.ID)
+				return nil
+			})
+			So(err, ShouldBeNil)
+			So(ids, ShouldResemble, []string{"1000", "1001"})
+
+			Convey("Stop", func() {
+				ids = nil
+				err := db.OrderBy("id").Iterate(ctx, &user, func() error {
+					ids = append(ids, user.ID)
+					return sq.ErrStop
+				})
+				So(err, ShouldBeNil)
+				So(ids, ShouldResemble, []string{"1000"})
+			})
+			Convey("Error", func() {
+				err := db.Where("id = ?", "1001").Iterate(ctx, &user, func() error {
+					So(&user, ShouldDeepEqual, users[1])
+					return errors.New("failed")
+				})
+				So(err, ShouldBeError, "failed")
+			})
+		})
 		Convey("Scan single row with simple where condition", func() {
 			{
 				var user User
@@ -236,7 +373,7 @@ func TestUser(t *testing.T) {
 				query, args, err := db.Where("id = ?", "1000").BuildUpdate(update)
 				So(err, ShouldBeNil)
 
-				expectedQuery := `UPDATE "user" SET "name"=$1,"int"=$2 WHERE (id = $3)`
+				expectedQuery := rebind(`UPDATE "user" SET "name"=$1,"int"=$2 WHERE (id = $3)`)
 				So(query, ShouldEqual, expectedQuery)
 				So(args, ShouldDeepEqual, []interface{}{
 					"Alice in wonderland",
@@ -263,7 +400,11 @@ func TestUser(t *testing.T) {
 				query, args, err := db.Where("id = ?", "1000").UpdateAll().BuildUpdate(update)
 				So(err, ShouldBeNil)
 
-				expectedQuery := `UPDATE "user" SET ("id","name","created_at","updated_at","bool","float64","int","int64","string","p_bool","p_float64","p_int","p_int64","p_string") = ($1,$2,$3,$4,$5,$6,$7,$8,$9,$10,$11,$12,$13,$14) WHERE (id = $15)`
+				expectedQuery := rebind(`UPDATE "user" SET ("id","name","created_at","updated_at","bool","float64","int","int64","string","p_bool","p_float64","p_int","p_int64","p_string") = ($1,$2,$3,$4,$5,$6,$7,$8,$9,$10,$11,$12,$13,$14) WHERE (id = $15)`)
+				if isMySQL() {
+					// MySQL does not support the tuple syntax.
+					expectedQuery = rebind(`UPDATE "user" SET "id" = $1,"name" = $2,"created_at" = $3,"updated_at" = $4,"bool" = $5,"float64" = $6,"int" = $7,"int64" = $8,"string" = $9,"p_bool" = $10,"p_float64" = $11,"p_int" = $12,"p_int64" = $13,"p_string" = $14 WHERE (id = $15)`)
+				}
 				So(query, ShouldEqual, expectedQuery)
 				So(len(args), ShouldEqual, 15)
 				So(args[1], ShouldEqual, "Alice in wonderland")
@@ -271,13 +412,113 @@ func TestUser(t *testing.T) {
 				So(args[14], ShouldEqual, "1000")
 			})
 		})
+		Convey("Update multiple objects", func() {
+			updates := []*User{
+				{ID: "1000", Name: "Alice in wonderland"},
+				{ID: "1001", Name: "Kattie Bell", Int: 100},
+				{ID: "1002", Name: "Not found"},
+			}
+			Convey("By primary key", func() {
+				n, err := db.Update(updates[0], updates[1], updates[2])
+				So(err, ShouldBeNil)
+				So(n, ShouldEqual, 2)
+
+				var _users Users
+				err = db.OrderBy("id").Find(&_users)
+				So(err, ShouldBeNil)
+				So(_users[0].Name, ShouldEqual, "Alice in wonderland")
+				So(_users[0].Int64, ShouldEqual, 1002)
+				So(_users[1].Name, ShouldEqual, "Kattie Bell")
+				So(_users[1].Int, ShouldEqual, 100)
+			})
+			Convey("Missing primary key", func() {
+				_, err := db.Update(updates[0], &User{Name: "Unknown"})
+				So(err, ShouldBeError, "missing id")
+
+				var user User
+				_, err = user.GetByPK(db, "1000")
+				So(err, ShouldBeNil)
+				So(user.Name, ShouldEqual, "Alice")
+			})
+			Convey("Reuse transaction", func() {
+				tx, err := db.Begin()
+				So(err, ShouldBeNil)
+				n, err := tx.Update(updates[0], updates[1])
+				So(err, ShouldBeNil)
+				So(n, ShouldEqual, 2)
+				So(tx.Rollback(), ShouldBeNil)
+
+				var user User
+				_, err = user.GetByPK(db, "1000")
+				So(err, ShouldBeNil)
+				So(user.Name, ShouldEqual, "Alice")
+			})
+			ConveyPostgres("Read-only transaction", func() {
+				var entry *sq.LogEntry
+				ldb := sq.MustConnect("postgres", connStr, sq.SetLogger(func(e *sq.LogEntry) { entry = e }))
+				tx, err := ldb.BeginTx(context.Background(), sq.TxOptions{Isolation: sql.LevelSerializable, ReadOnly: true})
+				So(err, ShouldBeNil)
+				_, err = tx.Update(updates[0])
+				So(err.Error(), ShouldContainSubstring, "read-only transaction")
+				So(tx.Rollback(), ShouldBeNil)
+
+				So(entry.Type(), ShouldEqual, sq.TypeRollback)
+				So(entry.Isolation, ShouldEqual, sql.LevelSerializable)
+			})
+			Convey("Transaction with canceled context", func() {
+				ctx, cancel := context.WithCancel(context.Background())
+				tx, err := db.BeginTx(ctx, sq.TxOptions{})
+				So(err, ShouldBeNil)
+				cancel()
+				_, err = tx.Update(updates[0])
+				So(err, ShouldNotBeNil)
+
+				var user User
+				_, err = user.GetByPK(db, "1000")
+				So(err, ShouldBeNil)
+				So(user.Name, ShouldEqual, "Alice")
+			})
+			Convey("UpdateValues: Build", func() {
+				query, args, err := db.NewQuery().BuildUpdateValues([]core.IUpdateValues{updates[0], updates[1]})
+				So(err, ShouldBeNil)
+
+				expectedQuery := rebind(`UPDATE "user" AS "_t" SET "name" = "_v"."name","created_at" = "_v"."created_at","updated_at" = "_v"."updated_at","bool" = "_v"."bool","float64" = "_v"."float64","int" = "_v"."int","int64" = "_v"."int64","string" = "_v"."string","p_bool" = "_v"."p_bool","p_float64" = "_v"."p_float64","p_int" = "_v"."p_int","p_int64" = "_v"."p_int64","p_string" = "_v"."p_string" FROM (SELECT "id","name","created_at","updated_at","bool","float64","int","int64","string","p_bool","p_float64","p_int","p_int64","p_string" FROM "user" WHERE false UNION ALL VALUES ($1,$2,$3,$4,$5,$6,$7,$8,$9,$10,$11,$12,$13,$14),($15,$16,$17,$18,$19,$20,$21,$22,$23,$24,$25,$26,$27,$28)) AS "_v" WHERE "_t"."id" = "_v"."id"`)
+				So(query, ShouldEqual, expectedQuery)
+				So(len(args), ShouldEqual, 28)
+			})
+			Convey("UpdateValues", func() {
+				n, err := db.UpdateValues().Update(updates[0], updates[1], updates[2])
+				So(err, ShouldBeNil)
+				So(n, ShouldEqual, 2)
+
+				var _users Users
+				err = db.OrderBy("id").Find(&_users)
+				So(err, ShouldBeNil)
+				So(_users[0].Name, ShouldEqual, "Alice in wonderland")
+				So(_users[0].Int64, ShouldEqual, 0)
+				So(_users[1].Name, ShouldEqual, "Kattie Bell")
+				So(_users[1].Int, ShouldEqual, 100)
+			})
+			// MySQL updates the objects one by one instead.
+			ConveyNotMySQL("UpdateValues with WHERE", func() {
+				_, err := db.Where("name = ?", "Alice").UpdateValues().Update(updates[0], updates[1])
+				So(err, ShouldBeError, "sqlgen: UPDATE ... FROM (VALUES ...) must not have WHERE")
+			})
+			ConveyNotMySQL("UpdateValues with Returning", func() {
+				_, err := db.Returning().UpdateValues().Update(updates[0], updates[1])
+				So(err, ShouldBeError, "sqlgen: UPDATE ... FROM (VALUES ...) does not support Returning")
+
+				_, _, err = db.Returning().BuildUpdateValues([]core.IUpdateValues{updates[0], updates[1]})
+				So(err, ShouldBeError, "sqlgen: UPDATE ... FROM (VALUES ...) does not support Returning")
+			})
+		})
 		Convey("Delete", func() {
 			q := db.Where("id = ?", "1000")
 			Convey("Build", func() {
 				query, args, err := q.BuildDelete(&User{})
 				So(err, ShouldBeNil)
 
-				expectedQuery := `DELETE FROM "user" WHERE (id = $1)`
+				expectedQuery := rebind(`DELETE FROM "user" WHERE (id = $1)`)
 				So(query, ShouldEqual, expectedQuery)
 				So(args, ShouldDeepEqual, []interface{}{"1000"})
 			})
@@ -293,27 +534,77 @@ func TestUser(t *testing.T) {
 				}
 			})
 		})
+		Convey("Primary key", func() {
+			Convey("Update without WHERE: Build", func() {
+				update := &User{ID: "1000", Name: "Alice in wonderland"}
+				query, args, err := db.NewQuery().BuildUpdate(update)
+				So(err, ShouldBeNil)
+
+				expectedQuery := rebind(`UPDATE "user" SET "id"=$1,"name"=$2 WHERE ("id" = $3)`)
+				So(query, ShouldEqual, expectedQuery)
+				So(args, ShouldDeepEqual, []interface{}{"1000", "Alice in wonderland", "1000"})
+			})
+			Convey("Delete without WHERE: Build", func() {
+				query, args, err := db.NewQuery().BuildDelete(&User{ID: "1000"})
+				So(err, ShouldBeNil)
+
+				expectedQuery := rebind(`DELETE FROM "user" WHERE ("id" = $1)`)
+				So(query, ShouldEqual, expectedQuery)
+				So(args, ShouldDeepEqual, []interface{}{"1000"})
+			})
+			Convey("Missing primary key", func() {
+				_, err := db.Delete(&User{})
+				So(err, ShouldBeError, "missing id")
+			})
+			Convey("GetByPK", func() {
+				var user User
+				has, err := user.GetByPK(db, "1001")
+				So(err, ShouldBeNil)
+				So(has, ShouldBeTrue)
+				So(&user, ShouldDeepEqual, users[1])
+			})
+			Convey("UpdateByPK", func() {
+				n, err := (&User{ID: "1001", Name: "Kattie Bell"}).UpdateByPK(db)
+				So(err, ShouldBeNil)
+				So(n, ShouldEqual, 1)
+
+				var user User
+				_, err = user.GetByPK(db, "1001")
+				So(err, ShouldBeNil)
+				So(user.Name, ShouldEqual, "Kattie Bell")
+			})
+			Convey("DeleteByPK", func() {
+				n, err := (&User{ID: "1001"}).DeleteByPK(db)
+				So(err, ShouldBeNil)
+				So(n, ShouldEqual, 1)
+
+				var user User
+				has, err := user.GetByPK(db, "1001")
+				So(err, ShouldBeNil)
+				So(has, ShouldBeFalse)
+			})
+		})
 		Convey("Join", func() {
 			Convey("Build", func() {
 				var userUnion UserUnion
 				query, _, err := db.NewQuery().BuildGet(&userUnion)
 				So(err, ShouldBeNil)
 
-				expectedQuery := `SELECT u."id",u."name",u."created_at",u."updated_at",u."bool",u."float64",u."int",u."int64",u."string",u."p_bool",u."p_float64",u."p_int",u."p_int64",u."p_string",ui."user_id",ui."metadata",ui."bool",ui."float64",ui."int",ui."int64",ui."string",ui."p_bool",ui."p_float64",ui."p_int",ui."p_int64",ui."p_string" FROM "user" AS u FULL JOIN "user_info" AS ui ON u.id = ui.user_id`
+				expectedQuery := rebind(`SELECT u."id",u."name",u."created_at",u."updated_at",u."bool",u."float64",u."int",u."int64",u."string",u."p_bool",u."p_float64",u."p_int",u."p_int64",u."p_string",ui."user_id",ui."metadata",ui."bool",ui."float64",ui."int",ui."int64",ui."string",ui."p_bool",ui."p_float64",ui."p_int",ui."p_int64",ui."p_string" FROM "user" AS u FULL JOIN "user_info" AS ui ON u.id = ui.user_id`)
 				So(query, ShouldEqual, expectedQuery)
 			})
 
 			Reset(func() {
-				db.MustExec(`TRUNCATE "user_info"`)
+				truncate(`"user_info"`)
 			})
-			Convey("Scan", func() {
+			ConveyNotMySQL("Scan", func() {
 				var userUnion UserUnion
 				has, err := db.Where(`u.id = ?`, "1000").Get(&userUnion)
 				So(err, ShouldBeNil)
 				So(has, ShouldEqual, true)
 				So(userUnion.User, ShouldDeepEqual, users[0])
 			})
-			Convey("Scan rows", func() {
+			ConveyNotMySQL("Scan rows", func() {
 				var userUnions UserUnions
 				err := db.Find(&userUnions)
 				So(err, ShouldBeNil)
@@ -326,7 +617,7 @@ func TestUser(t *testing.T) {
 	})
 	Convey("Array & JSON", t, func() {
 		Reset(func() {
-			db.MustExec(`TRUNCATE "complex_info"`)
+			truncate(`"complex_info"`)
 		})
 
 		address := Address{
@@ -422,15 +713,36 @@ func TestUser(t *testing.T) {
 				// "alias_p_time":    nil,
 			},
 		}
+		// The times in JSON are scanned back in UTC, except on MySQL which
+		// keeps their offset.
+		jsonLoc := time.UTC
+		if !isPostgres() {
+			// SQLite and MySQL store JSON and arrays as JSON text.
+			expectedTimes := `["2020-10-11T01:09:10.123Z","2021-10-11T01:09:10.123Z"]`
+			if isMySQL() {
+				expectedTimes = `["2020-10-11T08:09:10.123+07:00","2021-10-11T08:09:10.123+07:00"]`
+				jsonLoc = time.FixedZone("", 7*60*60)
+			}
+			item := expectedItems[0].(map[string]interface{})
+			item["address"] = `{"province":"p"}`
+			item["p_address"] = `{"province":"p"}`
+			item["metadata"] = `{"foo":"bar"}`
+			item["ints"] = `[1,2,3]`
+			item["int64s"] = `[4,5,6]`
+			item["strings"] = `["a","b","c"]`
+			item["times"] = expectedTimes
+			item["times_p"] = expectedTimes
+			expectedItems[1].(map[string]interface{})["address"] = `{"province":""}`
+		}
 		{
 			n, err := db.Insert(ComplexInfoes(items))
 			So(err, ShouldBeNil)
 			So(n, ShouldEqual, 2)
 
-			items[0].Times[0] = now0.In(time.UTC)
-			items[0].Times[1] = now1.In(time.UTC)
-			items[0].TimesP[0] = pTime(now0.In(time.UTC))
-			items[0].TimesP[1] = pTime(now1.In(time.UTC))
+			items[0].Times[0] = now0.In(jsonLoc)
+			items[0].Times[1] = now1.In(jsonLoc)
+			items[0].TimesP[0] = pTime(now0.In(jsonLoc))
+			items[0].TimesP[1] = pTime(now1.In(jsonLoc))
 			// items[0].AliasTime = AliasTime(now0.In(time.UTC))
 			// items[0].AliasPTime = AliasPTime(pTime(now1.In(time.UTC)))
 		}
@@ -439,7 +751,7 @@ func TestUser(t *testing.T) {
 			So(err, ShouldBeNil)
 			So(len(args), ShouldEqual, 38)
 
-			expectedQuery := `INSERT INTO "complex_info" ("id","address","p_address","metadata","ints","int64s","strings","times","times_p","alias_string","alias_int64","alias_int","alias_bool","alias_float64","alias_p_string","alias_p_int64","alias_p_int","alias_p_bool","alias_p_float64") VALUES ($1,$2,$3,$4,$5,$6,$7,$8,$9,$10,$11,$12,$13,$14,$15,$16,$17,$18,$19),($20,$21,$22,$23,$24,$25,$26,$27,$28,$29,$30,$31,$32,$33,$34,$35,$36,$37,$38)`
+			expectedQuery := rebind(`INSERT INTO "complex_info" ("id","address","p_address","metadata","ints","int64s","strings","times","times_p","alias_string","alias_int64","alias_int","alias_bool","alias_float64","alias_p_string","alias_p_int64","alias_p_int","alias_p_bool","alias_p_float64") VALUES ($1,$2,$3,$4,$5,$6,$7,$8,$9,$10,$11,$12,$13,$14,$15,$16,$17,$18,$19),($20,$21,$22,$23,$24,$25,$26,$27,$28,$29,$30,$31,$32,$33,$34,$35,$36,$37,$38)`)
 			So(query, ShouldEqual, expectedQuery)
 		})
 		Convey("Get again", func() {
@@ -460,13 +772,29 @@ func TestUser(t *testing.T) {
 			So(err, ShouldBeNil)
 			So(actual, ShouldResembleByKey("ID"), items)
 		})
+		ConveyPostgres("CopyFrom", func() {
+			truncate(`"complex_info"`)
+			merr.Reset()
+			n, err := db.CopyFrom(context.Background(), ComplexInfoes(items))
+			So(err, ShouldBeNil)
+			So(n, ShouldEqual, 2)
+
+			// One entry for COPY and one for commit
+			So(merr.Called, ShouldEqual, 2)
+			So(merr.Entry.TxQueries, ShouldHaveLength, 1)
+			So(merr.Entry.TxQueries[0].Query, ShouldStartWith, `COPY "complex_info" ("id", "address", `)
+			So(merr.Entry.TxQueries[0].Rows, ShouldEqual, 2)
+
+			actual := shouldQuery(db, `SELECT * FROM "complex_info"`)
+			So(actual, ShouldResembleByKey("id"), expectedItems)
+		})
 	})
 	Convey("Scan null values", t, func() {
 		Reset(func() {
-			db.MustExec(`TRUNCATE "user", "complex_info"`)
+			truncate(`"user"`, `"complex_info"`)
 		})
-		db.MustExec(`INSERT INTO "user" ("id") VALUES ($1)`, "1000")
-		db.MustExec(`INSERT INTO "complex_info" ("id") VALUES ($1)`, "1000")
+		db.MustExec(rebind(`INSERT INTO "user" ("id") VALUES ($1)`), "1000")
+		db.MustExec(rebind(`INSERT INTO "complex_info" ("id") VALUES ($1)`), "1000")
 
 		{
 			var users Users
@@ -486,7 +814,7 @@ func TestUser(t *testing.T) {
 			BuildCount((*UserUnion)(nil))
 		So(err, ShouldBeNil)
 
-		expectedQuery := `SELECT "order",COUNT(*) FROM "user" AS u FULL JOIN "user_info" AS ui ON u.id = ui.user_id WHERE (u.id = ui.user_id)`
+		expectedQuery := rebind(`SELECT "order",COUNT(*) FROM "user" AS u FULL JOIN "user_info" AS ui ON u.id = ui.user_id WHERE (u.id = ui.user_id)`)
 		So(query, ShouldEqual, expectedQuery)
 	})
 	Convey("Select and count without JOIN", t, func() {
@@ -497,11 +825,961 @@ func TestUser(t *testing.T) {
 			BuildCount((*User)(nil))
 		So(err, ShouldBeNil)
 
-		expectedQuery := `SELECT "order",COUNT(*) FROM "user" WHERE (status = $1)`
+		expectedQuery := rebind(`SELECT "order",COUNT(*) FROM "user" WHERE (status = $1)`)
 		So(query, ShouldEqual, expectedQuery)
 	})
 }
 
+func TestCompositeKey(t *testing.T) {
+	Convey("Composite key", t, func() {
+		Reset(func() {
+			truncate(`"account_user"`)
+		})
+
+		items := []*AccountUser{
+			{AccountID: "a1", UserID: "u1", Role: "owner"},
+			{AccountID: "a1", UserID: "u2", Role: "staff"},
+			{AccountID: "a2", UserID: "u1", Role: "staff"},
+		}
+		{
+			n, err := db.Insert(AccountUsers(items))
+			So(err, ShouldBeNil)
+			So(n, ShouldEqual, 3)
+		}
+		Convey("Delete without WHERE: Build", func() {
+			query, args, err := db.NewQuery().BuildDelete(&AccountUser{AccountID: "a1", UserID: "u2"})
+			So(err, ShouldBeNil)
+
+			expectedQuery := rebind(`DELETE FROM "account_user" WHERE ("account_id" = $1 AND "user_id" = $2)`)
+			So(query, ShouldEqual, expectedQuery)
+			So(args, ShouldDeepEqual, []interface{}{"a1", "u2"})
+		})
+		Convey("Missing a key column", func() {
+			_, err := db.Delete(&AccountUser{AccountID: "a1"})
+			So(err, ShouldBeError, "missing user_id")
+		})
+		Convey("GetByKey", func() {
+			var item AccountUser
+			has, err := item.GetByKey(db, AccountUserKey{AccountID: "a1", UserID: "u2"})
+			So(err, ShouldBeNil)
+			So(has, ShouldBeTrue)
+			So(&item, ShouldDeepEqual, items[1])
+		})
+		Convey("FindByKeys", func() {
+			var result AccountUsers
+			err := result.FindByKeys(db,
+				AccountUserKey{AccountID: "a1", UserID: "u1"},
+				AccountUserKey{AccountID: "a2", UserID: "u1"},
+				AccountUserKey{AccountID: "a2", UserID: "u2"},
+			)
+			So(err, ShouldBeNil)
+			So(result, ShouldResembleByKey("Role"), []*AccountUser{items[0], items[2]})
+		})
+		Convey("FindByKeys without key", func() {
+			var result AccountUsers
+			err := result.FindByKeys(db)
+			So(err, ShouldBeNil)
+			So(len(result), ShouldEqual, 0)
+		})
+	})
+}
+
+func TestPreload(t *testing.T) {
+	Convey("Preload", t, func() {
+		Reset(func() {
+			truncate(`"account"`, `"account_user"`, `"account_user_permission"`, `billing."invoice_v2"`, `"user"`, `"user_info"`)
+		})
+
+		accounts := []*Account{
+			{ID: "a1", Name: "Account 1"},
+			{ID: "a2", Name: "Account 2"},
+			{ID: "a3", Name: "Account 3"},
+		}
+		accountUsers := []*AccountUser{
+			{AccountID: "a1", UserID: "u1", Role: "owner"},
+			{AccountID: "a1", UserID: "u2", Role: "staff"},
+			{AccountID: "a2", UserID: "u1", Role: "owner"},
+		}
+		permissions := []*AccountUserPermission{
+			{AccountID: "a1", UserID: "u1", Permission: "read"},
+			{AccountID: "a1", UserID: "u1", Permission: "write"},
+			{AccountID: "a2", UserID: "u1", Permission: "read"},
+		}
+		invoices := []*Invoice{
+			{ID: "i1", AccountID: "a1", Amount: 100},
+			{ID: "i2", AccountID: "a2", Amount: 200},
+		}
+		{
+			_, err := db.Insert(Accounts(accounts))
+			So(err, ShouldBeNil)
+			_, err = db.Insert(AccountUsers(accountUsers))
+			So(err, ShouldBeNil)
+			_, err = db.Insert(AccountUserPermissions(permissions))
+			So(err, ShouldBeNil)
+			_, err = db.Insert(Invoices(invoices))
+			So(err, ShouldBeNil)
+		}
+
+		Convey("Get", func() {
+			var account Account
+			has, err := db.Preload("account_user").
+				Preload("invoice_v2").
+				Where("id = ?", "a1").Get(&account)
+			So(err, ShouldBeNil)
+			So(has, ShouldBeTrue)
+			So(account.Users, ShouldResembleByKey("UserID"), accountUsers[:2])
+			So(account.Invoices, ShouldDeepEqual, []*Invoice{invoices[0]})
+		})
+		Convey("Get without related rows", func() {
+			var account Account
+			has, err := db.Preload("account_user").Where("id = ?", "a3").Get(&account)
+			So(err, ShouldBeNil)
+			So(has, ShouldBeTrue)
+			So(account.Users, ShouldBeEmpty)
+		})
+		Convey("Get with predicates", func() {
+			var account Account
+			has, err := db.Preload("account_user", "role = ?", "staff").
+				Where("id = ?", "a1").Get(&account)
+			So(err, ShouldBeNil)
+			So(has, ShouldBeTrue)
+			So(account.Users, ShouldDeepEqual, []*AccountUser{accountUsers[1]})
+		})
+		Convey("Find", func() {
+			var result Accounts
+			err := db.Preload("account_user").
+				Preload("invoice_v2").
+				In("id", "a1", "a2").OrderBy("id").Find(&result)
+			So(err, ShouldBeNil)
+			So(len(result), ShouldEqual, 2)
+			So(result[0].Users, ShouldResembleByKey("UserID"), accountUsers[:2])
+			So(result[0].Invoices, ShouldDeepEqual, []*Invoice{invoices[0]})
+			So(result[1].Users, ShouldDeepEqual, []*AccountUser{accountUsers[2]})
+			So(result[1].Invoices, ShouldDeepEqual, []*Invoice{invoices[1]})
+		})
+		Convey("Find with composite key", func() {
+			var result AccountUsers
+			err := db.Preload("account_user_permission").
+				Where("user_id = ?", "u1").OrderBy("account_id").Find(&result)
+			So(err, ShouldBeNil)
+			So(len(result), ShouldEqual, 2)
+			So(result[0].Permissions, ShouldResembleByKey("Permission"), permissions[:2])
+			So(result[1].Permissions, ShouldDeepEqual, permissions[2:])
+		})
+		Convey("Find without rows", func() {
+			var result Accounts
+			err := db.Preload("account_user").Where("id = ?", "none").Find(&result)
+			So(err, ShouldBeNil)
+			So(len(result), ShouldEqual, 0)
+		})
+		Convey("Nested", func() {
+			merr.Reset()
+			var result Accounts
+			err := db.Preload("account_user.account_user_permission").
+				OrderBy("id").Find(&result)
+			So(err, ShouldBeNil)
+			So(merr.Called, ShouldEqual, 3) // one query per level
+			So(len(result), ShouldEqual, 3)
+			So(len(result[0].Users), ShouldEqual, 2)
+			for _, item := range result[0].Users {
+				switch item.UserID {
+				case "u1":
+					So(item.Permissions, ShouldResembleByKey("Permission"), permissions[:2])
+				case "u2":
+					So(item.Permissions, ShouldBeEmpty)
+				}
+			}
+			So(result[1].Users, ShouldHaveLength, 1)
+			So(result[1].Users[0].Permissions, ShouldDeepEqual, permissions[2:])
+			So(result[2].Users, ShouldBeEmpty)
+		})
+		Convey("Nested with predicates on each level", func() {
+			var account Account
+			has, err := db.Preload("account_user", "role = ?", "owner").
+				Preload("account_user.account_user_permission", func(q sq.CommonQuery) {
+					q.OrderBy("permission DESC").Limit(1)
+				}).
+				Preload("invoice_v2", "amount > ?", 100).
+				Where("id = ?", "a1").Get(&account)
+			So(err, ShouldBeNil)
+			So(has, ShouldBeTrue)
+			So(account.Users, ShouldHaveLength, 1)
+			So(account.Users[0].UserID, ShouldEqual, "u1")
+			So(account.Users[0].Permissions, ShouldDeepEqual, []*AccountUserPermission{permissions[1]})
+			So(account.Invoices, ShouldBeEmpty)
+		})
+		Convey("Nested: Unknown table", func() {
+			var result Accounts
+			err := db.Preload("account_user.unknown").Find(&result)
+			So(err, ShouldBeError, "sqlgen: *test.AccountUsers does not support preload table unknown")
+		})
+		Convey("Belongs-to", func() {
+			var result Invoices
+			err := db.Preload("account").OrderBy("id").Find(&result)
+			So(err, ShouldBeNil)
+			So(len(result), ShouldEqual, 2)
+			So(result[0].Account, ShouldDeepEqual, accounts[0])
+			So(result[1].Account, ShouldDeepEqual, accounts[1])
+		})
+		Convey("Belongs-to with nullable reference", func() {
+			truncate(`"user"`, `"user_info"`)
+			_, err := db.Insert(&User{ID: "u1", Name: "User 1"})
+			So(err, ShouldBeNil)
+			db.MustExec(`UPDATE billing."invoice_v2" SET approved_by = 'u1' WHERE id = 'i1'`)
+
+			var result Invoices
+			err = db.Preload("user").OrderBy("id").Find(&result)
+			So(err, ShouldBeNil)
+			So(len(result), ShouldEqual, 2)
+			So(result[0].Approver, ShouldNotBeNil)
+			So(result[0].Approver.ID, ShouldEqual, "u1")
+			So(result[1].Approver, ShouldBeNil)
+
+			var invoice Invoice
+			_, err = db.Preload("user").Where("id = ?", "i2").Get(&invoice)
+			So(err, ShouldBeNil)
+			So(invoice.Approver, ShouldBeNil)
+		})
+		Convey("Has-one", func() {
+			truncate(`"user"`, `"user_info"`)
+			users := []*User{{ID: "u1", Name: "User 1"}, {ID: "u2", Name: "User 2"}}
+			_, err := db.Insert(Users(users))
+			So(err, ShouldBeNil)
+			_, err = db.Insert(&UserInfo{UserID: "u1", Metadata: "info"})
+			So(err, ShouldBeNil)
+
+			var result Users
+			err = db.Preload("user_info").OrderBy("id").Find(&result)
+			So(err, ShouldBeNil)
+			So(len(result), ShouldEqual, 2)
+			So(result[0].Info, ShouldNotBeNil)
+			So(result[0].Info.Metadata, ShouldEqual, "info")
+			So(result[1].Info, ShouldBeNil)
+
+			var user User
+			_, err = db.Preload("user_info").Where("id = ?", "u1").Get(&user)
+			So(err, ShouldBeNil)
+			So(user.Info, ShouldNotBeNil)
+			So(user.Info.UserID, ShouldEqual, "u1")
+		})
+		Convey("Nested belongs-to", func() {
+			truncate(`"user"`, `"user_info"`)
+			_, err := db.Insert(&User{ID: "u1", Name: "User 1"})
+			So(err, ShouldBeNil)
+
+			var account Account
+			_, err = db.Preload("account_user.user").Where("id = ?", "a1").Get(&account)
+			So(err, ShouldBeNil)
+			So(len(account.Users), ShouldEqual, 2)
+			for _, item := range account.Users {
+				switch item.UserID {
+				case "u1":
+					So(item.User, ShouldNotBeNil)
+					So(item.User.Name, ShouldEqual, "User 1")
+				case "u2":
+					So(item.User, ShouldBeNil)
+				}
+			}
+		})
+		Convey("Unknown table", func() {
+			var result Accounts
+			err := db.Preload("unknown").Find(&result)
+			So(err, ShouldBeError, "sqlgen: *test.Accounts does not support preload table unknown")
+		})
+	})
+}
+
+func TestManyToMany(t *testing.T) {
+	Convey("Many-to-many", t, func() {
+		Reset(func() {
+			truncate(`"user"`, `"role"`, `"user_role"`)
+		})
+
+		users := []*User{{ID: "u1", Name: "User 1"}, {ID: "u2", Name: "User 2"}, {ID: "u3", Name: "User 3"}}
+		roles := []*Role{{ID: "r1", Name: "admin"}, {ID: "r2", Name: "staff"}, {ID: "r3", Name: "guest"}}
+		{
+			_, err := db.Insert(Users(users))
+			So(err, ShouldBeNil)
+			_, err = db.Insert(Roles(roles))
+			So(err, ShouldBeNil)
+
+			tx, err := db.Begin()
+			So(err, ShouldBeNil)
+			n, err := users[0].AddRoles(tx, roles[0], roles[1])
+			So(err, ShouldBeNil)
+			So(n, ShouldEqual, 2)
+			n, err = users[1].AddRoles(tx, roles[1])
+			So(err, ShouldBeNil)
+			So(n, ShouldEqual, 1)
+			So(tx.Commit(), ShouldBeNil)
+		}
+
+		Convey("Preload: Find", func() {
+			merr.Reset()
+			var result Users
+			err := db.Preload("role").OrderBy("id").Find(&result)
+			So(err, ShouldBeNil)
+			So(merr.Called, ShouldEqual, 3) // users, links and roles
+			So(len(result), ShouldEqual, 3)
+			So(result[0].Roles, ShouldResembleByKey("ID"), roles[:2])
+			So(result[1].Roles, ShouldDeepEqual, []*Role{roles[1]})
+			So(result[2].Roles, ShouldBeEmpty)
+		})
+		Convey("Preload: Get with predicates", func() {
+			var user User
+			has, err := db.Preload("role", "name = ?", "admin").Where("id = ?", "u1").Get(&user)
+			So(err, ShouldBeNil)
+			So(has, ShouldBeTrue)
+			So(user.Roles, ShouldDeepEqual, []*Role{roles[0]})
+		})
+		Convey("RemoveRoles", func() {
+			tx, err := db.Begin()
+			So(err, ShouldBeNil)
+			n, err := users[0].RemoveRoles(tx, roles[0], roles[2])
+			So(err, ShouldBeNil)
+			So(n, ShouldEqual, 1)
+			So(tx.Commit(), ShouldBeNil)
+
+			var user User
+			_, err = db.Preload("role").Where("id = ?", "u1").Get(&user)
+			So(err, ShouldBeNil)
+			So(user.Roles, ShouldDeepEqual, []*Role{roles[1]})
+		})
+		Convey("Link table with schema", func() {
+			Reset(func() {
+				truncate(`billing."invoice_v2"`, `billing."invoice_role"`)
+			})
+
+			invoice := &Invoice{ID: "i1", AccountID: "a1", Amount: 100}
+			_, err := db.Insert(invoice)
+			So(err, ShouldBeNil)
+
+			tx, err := db.Begin()
+			So(err, ShouldBeNil)
+			n, err := invoice.AddApproverRoles(tx, roles[0], roles[1], roles[2])
+			So(err, ShouldBeNil)
+			So(n, ShouldEqual, 3)
+			n, err = invoice.RemoveApproverRoles(tx, roles[2])
+			So(err, ShouldBeNil)
+			So(n, ShouldEqual, 1)
+			So(tx.Commit(), ShouldBeNil)
+
+			var item Invoice
+			_, err = db.Preload("role").Where("id = ?", "i1").Get(&item)
+			So(err, ShouldBeNil)
+			So(item.ApproverRoles, ShouldDeepEqual, []*Role{roles[0], roles[1]})
+		})
+		Convey("AddRoles: Rollback", func() {
+			tx, err := db.Begin()
+			So(err, ShouldBeNil)
+			_, err = users[2].AddRoles(tx, roles[2])
+			So(err, ShouldBeNil)
+			So(tx.Rollback(), ShouldBeNil)
+
+			var user User
+			_, err = db.Preload("role").Where("id = ?", "u3").Get(&user)
+			So(err, ShouldBeNil)
+			So(user.Roles, ShouldBeEmpty)
+		})
+	})
+}
+
+// roleHooks records the hooks called on Role, which are only defined for
+// testing.
+var roleHooks struct {
+	calls []string
+	fail  string
+}
+
+func (m *Role) hook(name string, tx sq.Tx) error {
+	roleHooks.calls = append(roleHooks.calls, fmt.Sprintf("%v %v tx=%v", name, m.ID, tx != nil))
+	if roleHooks.fail == name {
+		return errors.New(name + " failed")
+	}
+	return nil
+}
+
+func (m *Role) BeforeInsert(ctx context.Context, tx sq.Tx) error { return m.hook("BeforeInsert", tx) }
+func (m *Role) AfterInsert(ctx context.Context, tx sq.Tx) error  { return m.hook("AfterInsert", tx) }
+func (m *Role) BeforeUpdate(ctx context.Context, tx sq.Tx) error { return m.hook("BeforeUpdate", tx) }
+func (m *Role) AfterUpdate(ctx context.Context, tx sq.Tx) error  { return m.hook("AfterUpdate", tx) }
+func (m *Role) BeforeDelete(ctx context.Context, tx sq.Tx) error { return m.hook("BeforeDelete", tx) }
+func (m *Role) AfterDelete(ctx context.Context, tx sq.Tx) error  { return m.hook("AfterDelete", tx) }
+func (m *Role) AfterFind(ctx context.Context, tx sq.Tx) error    { return m.hook("AfterFind", tx) }
+
+// userInfoHooks records the hooks without context called on UserInfo.
+var userInfoHooks []string
+
+func (m *UserInfo) BeforeInsert() error {
+	userInfoHooks = append(userInfoHooks, "BeforeInsert "+m.UserID)
+	return nil
+}
+
+func (m *UserInfo) BeforeUpdate() error {
+	userInfoHooks = append(userInfoHooks, "BeforeUpdate")
+	return nil
+}
+
+func TestHooks(t *testing.T) {
+	Convey("Hooks", t, func() {
+		Reset(func() {
+			truncate(`"role"`)
+			roleHooks.calls, roleHooks.fail = nil, ""
+		})
+		roleHooks.calls = nil
+
+		roles := []*Role{{ID: "r1", Name: "admin"}, {ID: "r2", Name: "staff"}}
+		countRoles := func() (n int) {
+			So(db.QueryRow(`SELECT COUNT(*) FROM "role"`).Scan(&n), ShouldBeNil)
+			return n
+		}
+
+		Convey("Insert", func() {
+			n, err := db.Insert(roles[0], roles[1])
+			So(err, ShouldBeNil)
+			So(n, ShouldEqual, 2)
+			So(roleHooks.calls, ShouldResemble, []string{
+				"BeforeInsert r1 tx=true", "AfterInsert r1 tx=true",
+				"BeforeInsert r2 tx=true", "AfterInsert r2 tx=true",
+			})
+		})
+		Convey("CopyFrom does not call hooks", func() {
+			n, err := db.CopyFrom(context.Background(), Roles(roles))
+			So(err, ShouldBeNil)
+			So(n, ShouldEqual, 2)
+			So(countRoles(), ShouldEqual, 2)
+			So(roleHooks.calls, ShouldBeEmpty)
+		})
+		Convey("Insert slice", func() {
+			_, err := db.Insert(Roles(roles))
+			So(err, ShouldBeNil)
+			So(roleHooks.calls, ShouldResemble, []string{
+				"BeforeInsert r1 tx=true", "BeforeInsert r2 tx=true",
+				"AfterInsert r1 tx=true", "AfterInsert r2 tx=true",
+			})
+		})
+		Convey("Error rolls back", func() {
+			roleHooks.fail = "AfterInsert"
+			_, err := db.Insert(Roles(roles))
+			So(err, ShouldBeError, "AfterInsert failed")
+			So(countRoles(), ShouldEqual, 0)
+		})
+		Convey("Error aborts the statement", func() {
+			roleHooks.fail = "BeforeInsert"
+			_, err := db.Insert(roles[0])
+			So(err, ShouldBeError, "BeforeInsert failed")
+			So(roleHooks.calls, ShouldResemble, []string{"BeforeInsert r1 tx=true"})
+			So(countRoles(), ShouldEqual, 0)
+		})
+		Convey("Update, Delete and Find", func() {
+			_, err := db.Insert(Roles(roles))
+			So(err, ShouldBeNil)
+			roleHooks.calls = nil
+
+			_, err = db.Update(&Role{ID: "r1", Name: "root"})
+			So(err, ShouldBeNil)
+			_, err = db.Delete(&Role{ID: "r2"})
+			So(err, ShouldBeNil)
+
+			var result Roles
+			So(db.Find(&result), ShouldBeNil)
+			So(roleHooks.calls, ShouldResemble, []string{
+				"BeforeUpdate r1 tx=true", "AfterUpdate r1 tx=true",
+				"BeforeDelete r2 tx=true", "AfterDelete r2 tx=true",
+				"AfterFind r1 tx=false",
+			})
+		})
+		Convey("Delete error rolls back", func() {
+			_, err := db.Insert(Roles(roles))
+			So(err, ShouldBeNil)
+
+			roleHooks.fail = "AfterDelete"
+			_, err = db.Delete(&Role{ID: "r2"})
+			So(err, ShouldBeError, "AfterDelete failed")
+			So(countRoles(), ShouldEqual, 2)
+		})
+		Convey("Error in an active transaction", func() {
+			tx, err := db.Begin()
+			So(err, ShouldBeNil)
+			_, err = tx.Insert(roles[0])
+			So(err, ShouldBeNil)
+
+			roleHooks.fail = "AfterInsert"
+			_, err = tx.Insert(roles[1])
+			So(err, ShouldBeError, "AfterInsert failed")
+			So(tx.Commit(), ShouldBeNil)
+			So(countRoles(), ShouldEqual, 1)
+		})
+		Convey("Hooks without context", func() {
+			userInfoHooks = nil
+			info := &UserInfo{UserID: "u1"}
+			_, err := db.Insert(info)
+			So(err, ShouldBeNil)
+			_, err = db.Where("user_id = ?", "u1").Update(&UserInfo{Metadata: "info"})
+			So(err, ShouldBeNil)
+			So(userInfoHooks, ShouldResemble, []string{"BeforeInsert u1", "BeforeUpdate"})
+			truncate(`"user_info"`)
+		})
+		Convey("Active transaction", func() {
+			tx, err := db.Begin()
+			So(err, ShouldBeNil)
+			_, err = tx.Insert(roles[0])
+			So(err, ShouldBeNil)
+
+			var role Role
+			_, err = tx.Get(&role, "id = ?", "r1")
+			So(err, ShouldBeNil)
+			So(tx.Rollback(), ShouldBeNil)
+			So(roleHooks.calls, ShouldResemble, []string{
+				"BeforeInsert r1 tx=true", "AfterInsert r1 tx=true", "AfterFind r1 tx=true",
+			})
+		})
+	})
+}
+
+func TestUpsert(t *testing.T) {
+	Convey("Upsert", t, func() {
+		Reset(func() {
+			truncate(`"setting"`)
+		})
+
+		settings := []*Setting{
+			{Key: "k1", Value: "v1", CreatedAt: now0, UpdatedAt: now0},
+			{Key: "k2", Value: "v2", CreatedAt: now0, UpdatedAt: now0},
+		}
+		{
+			n, err := db.Upsert(Settings(settings))
+			So(err, ShouldBeNil)
+			So(n, ShouldEqual, 2)
+		}
+		getSetting := func(key string) *Setting {
+			var setting Setting
+			has, err := db.Where("key = ?", key).Get(&setting)
+			So(err, ShouldBeNil)
+			So(has, ShouldBeTrue)
+			return &setting
+		}
+
+		Convey("Build", func() {
+			query, args, err := db.NewQuery().BuildUpsert(settings[0])
+			So(err, ShouldBeNil)
+			So(len(args), ShouldEqual, 4)
+
+			expectedQuery := rebind(`INSERT INTO "setting" ("key","value","created_at","updated_at") VALUES ($1,$2,$3,$4) ON CONFLICT ("key") DO UPDATE SET "value" = EXCLUDED."value","created_at" = EXCLUDED."created_at","updated_at" = EXCLUDED."updated_at"`)
+			if isMySQL() {
+				expectedQuery = rebind(`INSERT INTO "setting" ("key","value","created_at","updated_at") VALUES ($1,$2,$3,$4) ON DUPLICATE KEY UPDATE "value" = VALUES("value"),"created_at" = VALUES("created_at"),"updated_at" = VALUES("updated_at")`)
+			}
+			So(query, ShouldEqual, expectedQuery)
+		})
+		Convey("Build: DoNothing", func() {
+			query, _, err := db.DoNothing().BuildUpsert(settings[0])
+			So(err, ShouldBeNil)
+
+			expectedQuery := rebind(`INSERT INTO "setting" ("key","value","created_at","updated_at") VALUES ($1,$2,$3,$4) ON CONFLICT ("key") DO NOTHING`)
+			if isMySQL() {
+				// MySQL emulates DO NOTHING with a no-op update.
+				expectedQuery = rebind(`INSERT INTO "setting" ("key","value","created_at","updated_at") VALUES ($1,$2,$3,$4) ON DUPLICATE KEY UPDATE "key" = "key"`)
+			}
+			So(query, ShouldEqual, expectedQuery)
+		})
+		Convey("Build: OnConflict, DoUpdate and SkipCreated", func() {
+			query, _, err := db.OnConflict("key").DoUpdate("value", "created_at").SkipCreated().BuildUpsert(Settings(settings))
+			So(err, ShouldBeNil)
+
+			expectedQuery := rebind(`INSERT INTO "setting" ("key","value","created_at","updated_at") VALUES ($1,$2,$3,$4),($5,$6,$7,$8) ON CONFLICT ("key") DO UPDATE SET "value" = EXCLUDED."value"`)
+			if isMySQL() {
+				expectedQuery = rebind(`INSERT INTO "setting" ("key","value","created_at","updated_at") VALUES ($1,$2,$3,$4),($5,$6,$7,$8) ON DUPLICATE KEY UPDATE "value" = VALUES("value")`)
+			}
+			So(query, ShouldEqual, expectedQuery)
+		})
+		Convey("Build: Without primary key", func() {
+			_, _, err := db.NewQuery().BuildUpsert(&ComplexInfo{ID: "c1"})
+			So(err, ShouldBeError, "sqlgen: upsert ComplexInfo without primary key requires OnConflict columns")
+
+			_, _, err = db.DoNothing().BuildUpsert(&ComplexInfo{ID: "c1"})
+			So(err, ShouldBeError, "sqlgen: upsert ComplexInfo without primary key requires OnConflict columns")
+
+			query, _, err := db.OnConflict("id").DoNothing().BuildUpsert(&ComplexInfo{ID: "c1"})
+			So(err, ShouldBeNil)
+			if isMySQL() {
+				So(query, ShouldEndWith, rebind(`ON DUPLICATE KEY UPDATE "id" = "id"`))
+			} else {
+				So(query, ShouldEndWith, `ON CONFLICT ("id") DO NOTHING`)
+			}
+
+			query, _, err = db.OnConflict("id").BuildUpsert(&ComplexInfo{ID: "c1"})
+			So(err, ShouldBeNil)
+			if isMySQL() {
+				So(query, ShouldContainSubstring, rebind(`ON DUPLICATE KEY UPDATE "address" = VALUES("address")`))
+			} else {
+				So(query, ShouldContainSubstring, `ON CONFLICT ("id") DO UPDATE SET`)
+			}
+		})
+		Convey("Update on conflict", func() {
+			n, err := db.Upsert(&Setting{Key: "k1", Value: "v1.1", CreatedAt: now1}, &Setting{Key: "k3", Value: "v3"})
+			So(err, ShouldBeNil)
+			if isMySQL() {
+				// MySQL counts an updated row as 2 affected rows.
+				So(n, ShouldEqual, 3)
+			} else {
+				So(n, ShouldEqual, 2)
+			}
+
+			setting := getSetting("k1")
+			So(setting.Value, ShouldEqual, "v1.1")
+			So(setting.CreatedAt.Equal(now1), ShouldBeTrue)
+			So(setting.UpdatedAt.After(now1), ShouldBeTrue)
+			So(getSetting("k3").Value, ShouldEqual, "v3")
+		})
+		Convey("Skip created", func() {
+			_, err := db.SkipCreated().Upsert(&Setting{Key: "k1", Value: "v1.1", CreatedAt: now1})
+			So(err, ShouldBeNil)
+
+			setting := getSetting("k1")
+			So(setting.Value, ShouldEqual, "v1.1")
+			So(setting.CreatedAt.Equal(now0), ShouldBeTrue)
+		})
+		Convey("Do nothing", func() {
+			n, err := db.DoNothing().Upsert(&Setting{Key: "k1", Value: "v1.1"})
+			So(err, ShouldBeNil)
+			So(n, ShouldEqual, 0)
+			So(getSetting("k1").Value, ShouldEqual, "v1")
+		})
+	})
+}
+
+func TestReturning(t *testing.T) {
+	Convey("Returning", t, func() {
+		Reset(func() {
+			truncate(`"setting"`)
+		})
+
+		Convey("Build", func() {
+			query, _, err := db.Returning().BuildInsert(&Setting{Key: "k1"})
+			So(err, ShouldBeNil)
+
+			expectedQuery := rebind(`INSERT INTO "setting" ("key","value","created_at","updated_at") VALUES ($1,$2,$3,$4) RETURNING "key","value","created_at","updated_at"`)
+			if isMySQL() {
+				// MySQL reloads the row after the statement instead.
+				expectedQuery = rebind(`INSERT INTO "setting" ("key","value","created_at","updated_at") VALUES ($1,$2,$3,$4)`)
+			}
+			So(query, ShouldEqual, expectedQuery)
+		})
+		Convey("Insert", func() {
+			setting := &Setting{Key: "k1", Value: "v1"}
+			n, err := db.Returning().Insert(setting)
+			So(err, ShouldBeNil)
+			So(n, ShouldEqual, 1)
+			So(setting.CreatedAt.IsZero(), ShouldBeFalse)
+			So(setting.UpdatedAt, ShouldResemble, setting.CreatedAt)
+		})
+		ConveyNotMySQL("Insert multiple rows", func() {
+			settings := []*Setting{{Key: "k1", Value: "v1"}, {Key: "k2", Value: "v2", CreatedAt: now0}}
+			n, err := db.Returning().Insert(Settings(settings))
+			So(err, ShouldBeNil)
+			So(n, ShouldEqual, 2)
+			So(settings[0].CreatedAt.IsZero(), ShouldBeFalse)
+			So(settings[1].CreatedAt.Equal(now0), ShouldBeTrue)
+			So(settings[1].UpdatedAt.IsZero(), ShouldBeFalse)
+		})
+		Convey("Update", func() {
+			_, err := db.Insert(&Setting{Key: "k1", Value: "v1", CreatedAt: now0})
+			So(err, ShouldBeNil)
+
+			setting := &Setting{Key: "k1", Value: "v1.1"}
+			n, err := db.Returning().Update(setting)
+			So(err, ShouldBeNil)
+			So(n, ShouldEqual, 1)
+			So(setting.Value, ShouldEqual, "v1.1")
+			So(setting.CreatedAt.Equal(now0), ShouldBeTrue)
+			So(setting.UpdatedAt.After(now0), ShouldBeTrue)
+		})
+		ConveyNotMySQL("Upsert: DoNothing with a conflict in the middle", func() {
+			_, err := db.Insert(&Setting{Key: "k2", Value: "v2", CreatedAt: now0})
+			So(err, ShouldBeNil)
+
+			settings := []*Setting{{Key: "k1", Value: "v1"}, {Key: "k2", Value: "v2.1"}, {Key: "k3", Value: "v3", CreatedAt: now1}}
+			n, err := db.Returning().DoNothing().Upsert(Settings(settings))
+			So(err, ShouldBeNil)
+			So(n, ShouldEqual, 2)
+			So(settings[0].Key, ShouldEqual, "k1")
+			So(settings[0].CreatedAt.IsZero(), ShouldBeFalse)
+			So(settings[1].Value, ShouldEqual, "v2.1")
+			So(settings[1].CreatedAt.IsZero(), ShouldBeTrue)
+			So(settings[2].Key, ShouldEqual, "k3")
+			So(settings[2].Value, ShouldEqual, "v3")
+			So(settings[2].CreatedAt.Equal(now1), ShouldBeTrue)
+		})
+		Convey("Upsert: Without primary key", func() {
+			_, err := db.Returning().OnConflict("id").DoNothing().Upsert(&ComplexInfo{ID: "c1"})
+			if isMySQL() {
+				So(err, ShouldBeError, "sqlgen: *test.ComplexInfo must have primary key to return columns without RETURNING")
+			} else {
+				So(err, ShouldBeError, "sqlgen: *test.ComplexInfo must have primary key to return columns of multiple rows or DoNothing")
+			}
+		})
+		Convey("Update not found", func() {
+			n, err := db.Returning().Update(&Setting{Key: "k1", Value: "v1.1"})
+			So(err, ShouldBeNil)
+			So(n, ShouldEqual, 0)
+		})
+	})
+}
+
+func TestInsertChunks(t *testing.T) {
+	Convey("Insert in chunks", t, func() {
+		Reset(func() {
+			truncate(`"setting"`)
+		})
+
+		// 4 columns per row, therefore 2 rows per statement
+		db := connect(sq.MaxParams(8), sq.SetErrorMapper(merr.Mock))
+		settings := make([]*Setting, 5)
+		for i := range settings {
+			settings[i] = &Setting{Key: fmt.Sprintf("k%v", i), Value: "v"}
+		}
+
+		Convey("Insert", func() {
+			merr.Reset()
+			n, err := db.Insert(Settings(settings))
+			So(err, ShouldBeNil)
+			So(n, ShouldEqual, 5)
+			So(merr.Called, ShouldEqual, 4) // 3 statements and commit
+			So(merr.Entry.Flags.Type(), ShouldEqual, sq.TypeCommit)
+			So(len(merr.Entry.TxQueries), ShouldEqual, 3)
+
+			var count int
+			So(db.QueryRow(`SELECT COUNT(*) FROM "setting"`).Scan(&count), ShouldBeNil)
+			So(count, ShouldEqual, 5)
+		})
+		Convey("Rollback all chunks", func() {
+			_, err := db.Insert(&Setting{Key: "k3"})
+			So(err, ShouldBeNil)
+
+			_, err = db.Insert(Settings(settings))
+			So(err, ShouldNotBeNil)
+
+			var count int
+			So(db.QueryRow(`SELECT COUNT(*) FROM "setting"`).Scan(&count), ShouldBeNil)
+			So(count, ShouldEqual, 1)
+		})
+		Convey("UpdateValues with more columns than parameters", func() {
+			db := connect(sq.MaxParams(3))
+			_, err := db.Insert(Settings(settings[:2]))
+			So(err, ShouldBeNil)
+
+			n, err := db.UpdateValues().Update(&Setting{Key: "k0", Value: "v0"}, &Setting{Key: "k1", Value: "v1"})
+			So(err, ShouldBeNil)
+			So(n, ShouldEqual, 2)
+
+			var items Settings
+			So(db.OrderBy("key").Find(&items), ShouldBeNil)
+			So(items[0].Value, ShouldEqual, "v0")
+			So(items[1].Value, ShouldEqual, "v1")
+		})
+		Convey("UpdateValues: Build without objects", func() {
+			_, _, err := db.NewQuery().BuildUpdateValues(nil)
+			So(err, ShouldBeError, "sqlgen: UPDATE ... FROM (VALUES ...) requires at least one object")
+		})
+	})
+}
+
+func TestCursor(t *testing.T) {
+	Convey("Keyset pagination", t, func() {
+		Reset(func() {
+			truncate(`"setting"`)
+		})
+
+		settings := make(Settings, 5)
+		for i := range settings {
+			settings[i] = &Setting{Key: fmt.Sprintf("k%v", i), Value: "v"}
+		}
+		_, err := db.Insert(settings)
+		So(err, ShouldBeNil)
+
+		keysOf := func(settings Settings) (keys []string) {
+			for _, s := range settings {
+				keys = append(keys, s.Key)
+			}
+			return keys
+		}
+
+		Convey("Build", func() {
+			cursor, err := db.OrderBy("value", "key").Cursor(settings[1])
+			So(err, ShouldBeNil)
+
+			query, args, err := db.OrderBy("value", "key").Limit(2).After(cursor).BuildFind(&Settings{})
+			So(err, ShouldBeNil)
+			So(query, ShouldEndWith, rebind(` FROM "setting" WHERE (("value","key") > ($1,$2)) ORDER BY "value","key" LIMIT 2`))
+			So(args, ShouldResemble, []interface{}{"v", "k1"})
+
+			cursor, err = db.OrderBy("key DESC").Cursor(settings[1])
+			So(err, ShouldBeNil)
+
+			query, _, err = db.OrderBy("key DESC").Before(cursor).BuildFind(&Settings{})
+			So(err, ShouldBeNil)
+			So(query, ShouldEndWith, rebind(` WHERE (("key") > ($1)) ORDER BY "key"`))
+		})
+		Convey("After", func() {
+			var pages [][]string
+			cursor := ""
+			for {
+				var items Settings
+				q := db.OrderBy("key").Limit(2).After(cursor)
+				So(q.Find(&items), ShouldBeNil)
+				if len(items) == 0 {
+					break
+				}
+				pages = append(pages, keysOf(items))
+				cursor, err = q.Cursor(items[len(items)-1])
+				So(err, ShouldBeNil)
+			}
+			So(pages, ShouldResemble, [][]string{{"k0", "k1"}, {"k2", "k3"}, {"k4"}})
+		})
+		Convey("Before", func() {
+			cursor, err := db.OrderBy("key DESC").Cursor(settings[1])
+			So(err, ShouldBeNil)
+
+			var items Settings
+			So(db.OrderBy("key DESC").Limit(2).Before(cursor).Find(&items), ShouldBeNil)
+			So(keysOf(items), ShouldResemble, []string{"k3", "k2"})
+
+			So(db.OrderBy("key DESC").Limit(2).Before("").Find(&items), ShouldBeNil)
+			So(keysOf(items), ShouldResemble, []string{"k1", "k0"})
+		})
+		Convey("Time column", func() {
+			truncate(`"setting"`)
+			// Later keys are created earlier
+			t0 := now0.In(time.UTC)
+			for i, s := range settings {
+				s.CreatedAt = t0.Add(time.Duration(len(settings)-i) * time.Hour)
+			}
+			_, err := db.Insert(settings)
+			So(err, ShouldBeNil)
+
+			var pages [][]string
+			cursor := ""
+			for {
+				var items Settings
+				q := db.OrderBy("created_at", "key").Limit(2).After(cursor)
+				So(q.Find(&items), ShouldBeNil)
+				if len(items) == 0 {
+					break
+				}
+				pages = append(pages, keysOf(items))
+				cursor, err = q.Cursor(items[len(items)-1])
+				So(err, ShouldBeNil)
+			}
+			So(pages, ShouldResemble, [][]string{{"k4", "k3"}, {"k2", "k1"}, {"k0"}})
+
+			cursor, err = db.OrderBy("created_at").Cursor(settings[0])
+			So(err, ShouldBeNil)
+			_, args, err := db.OrderBy("created_at").After(cursor).BuildFind(&Settings{})
+			So(err, ShouldBeNil)
+			So(args, ShouldHaveLength, 1)
+			So(args[0], ShouldHaveSameTypeAs, time.Time{})
+			So(args[0].(time.Time).Equal(settings[0].CreatedAt), ShouldBeTrue)
+		})
+		Convey("Empty string", func() {
+			cursor, err := db.OrderBy("value", "key").Cursor(&Setting{Key: "k1"})
+			So(err, ShouldBeNil)
+
+			_, args, err := db.OrderBy("value", "key").After(cursor).BuildFind(&Settings{})
+			So(err, ShouldBeNil)
+			So(args, ShouldResemble, []interface{}{"", "k1"})
+		})
+		Convey("Join", func() {
+			user := &User{ID: "1", Name: "a"}
+			cursor, err := db.OrderBy("u.name", "u.id").Cursor(&UserUnion{User: user})
+			So(err, ShouldBeNil)
+
+			query, args, err := db.OrderBy("u.name", "u.id").After(cursor).BuildFind(&UserUnions{})
+			So(err, ShouldBeNil)
+			So(query, ShouldEndWith, rebind(` WHERE ((u.name,u.id) > ($1,$2)) ORDER BY u.name,u.id`))
+			So(args, ShouldResemble, []interface{}{"a", "1"})
+		})
+		Convey("Mixed directions", func() {
+			_, _, err := db.OrderBy("value DESC", "key").After("").BuildFind(&Settings{})
+			So(err, ShouldBeError, "sqlgen: cursor requires the same direction for all ORDER BY columns")
+		})
+		Convey("Invalid cursor", func() {
+			err := db.OrderBy("key").After("invalid").Find(&Settings{})
+			So(err, ShouldBeError, "sqlgen: invalid cursor")
+		})
+	})
+}
+
+func TestTableName(t *testing.T) {
+	Convey("Declared table name", t, func() {
+		Convey("Subset uses the table of its declaration", func() {
+			query, _, err := db.NewQuery().Where("id = ?", "1000").BuildGet(&UserSubset{})
+			So(err, ShouldBeNil)
+			So(query, ShouldStartWith, rebind(`SELECT "id","bool",`))
+			So(query, ShouldEndWith, rebind(` FROM "user" WHERE (id = $1)`))
+		})
+		Convey("Join with subset", func() {
+			query, _, err := db.NewQuery().BuildFind(&UserUnionMores{})
+			So(err, ShouldBeNil)
+			So(query, ShouldContainSubstring, rebind(`RIGHT JOIN "user" AS us ON u.id = us.id`))
+		})
+	})
+	Convey("Declared schema", t, func() {
+		Reset(func() {
+			truncate(`billing."invoice_v2"`)
+		})
+
+		invoices := []*Invoice{
+			{ID: "i1", AccountID: "a1", Amount: 100},
+			{ID: "i2", AccountID: "a1", Amount: 200},
+			{ID: "i3", AccountID: "a2", Amount: 300},
+		}
+		{
+			n, err := db.Insert(Invoices(invoices))
+			So(err, ShouldBeNil)
+			So(n, ShouldEqual, 3)
+		}
+		Convey("Get", func() {
+			var item Invoice
+			has, err := item.GetByPK(db, "i2")
+			So(err, ShouldBeNil)
+			So(has, ShouldBeTrue)
+			So(&item, ShouldDeepEqual, invoices[1])
+		})
+		Convey("Update: Build", func() {
+			query, _, err := db.NewQuery().BuildUpdate(&Invoice{ID: "i1", Amount: 150})
+			So(err, ShouldBeNil)
+
+			expectedQuery := rebind(`UPDATE billing."invoice_v2" SET "id"=$1,"amount"=$2 WHERE ("id" = $3)`)
+			So(query, ShouldEqual, expectedQuery)
+		})
+		Convey("Delete: Build", func() {
+			query, _, err := db.NewQuery().BuildDelete(&Invoice{ID: "i1"})
+			So(err, ShouldBeNil)
+
+			expectedQuery := rebind(`DELETE FROM billing."invoice_v2" WHERE ("id" = $1)`)
+			So(query, ShouldEqual, expectedQuery)
+		})
+		Convey("Count: Build", func() {
+			query, _, err := db.NewQuery().Where("account_id = ?", "a1").BuildCount((*Invoice)(nil))
+			So(err, ShouldBeNil)
+
+			expectedQuery := rebind(`SELECT COUNT(*) FROM billing."invoice_v2" WHERE (account_id = $1)`)
+			So(query, ShouldEqual, expectedQuery)
+
+			mdb := sq.MustConnect("mysql", mysqlConnStr)
+			defer mdb.DB().Close()
+			query, _, err = mdb.NewQuery().Where("account_id = ?", "a1").BuildCount((*Invoice)(nil))
+			So(err, ShouldBeNil)
+			So(query, ShouldEqual, "SELECT COUNT(*) FROM billing.`invoice_v2` WHERE (account_id = ?)")
+		})
+		Convey("Count", func() {
+			n, err := db.Where("account_id = ?", "a1").Count((*Invoice)(nil))
+			So(err, ShouldBeNil)
+			So(n, ShouldEqual, 2)
+		})
+	})
+}
+
 func TestErrorMapper(t *testing.T) {
 	merr.Reset()
 	Convey("ErrorMapper", t, func() {
@@ -526,7 +1804,7 @@ func TestErrorMapper(t *testing.T) {
 			So(merr.Entry.Query, ShouldEqual, `SELECT 1 WHERE (false)`)
 			So(merr.Err, ShouldEqual, sql.ErrNoRows)
 		})
-		Convey("Scan: Other error", func() {
+		ConveyPostgres("Scan: Other error", func() {
 			var v int
 			err := db.Select("a").Scan(&v)
 			So(err, ShouldNotBeNil)
@@ -546,7 +1824,7 @@ func TestErrorMapper(t *testing.T) {
 			So(err, ShouldBeNil)
 
 			So(merr.Called, ShouldEqual, 2)
-			So(merr.Entry.Query, ShouldContainSubstring, `FROM "user"`)
+			So(merr.Entry.Query, ShouldContainSubstring, rebind(`FROM "user"`))
 		})
 		Convey("Get: ErrNoRows", func() {
 			var user User
@@ -554,7 +1832,7 @@ func TestErrorMapper(t *testing.T) {
 			So(err, ShouldBeNil)
 
 			So(merr.Called, ShouldEqual, 1)
-			So(merr.Entry.Query, ShouldContainSubstring, `FROM "user"`)
+			So(merr.Entry.Query, ShouldContainSubstring, rebind(`FROM "user"`))
 			So(merr.Err, ShouldEqual, sql.ErrNoRows)
 		})
 		Convey("Get: Other error", func() {
@@ -563,8 +1841,15 @@ func TestErrorMapper(t *testing.T) {
 			So(err, ShouldNotBeNil)
 
 			So(merr.Called, ShouldEqual, 1)
-			So(merr.Entry.Query, ShouldContainSubstring, `FROM "user"`)
-			So(merr.Err, ShouldBeError, `pq: column "invalid" does not exist`)
+			So(merr.Entry.Query, ShouldContainSubstring, rebind(`FROM "user"`))
+			switch {
+			case isPostgres():
+				So(merr.Err, ShouldBeError, `pq: column "invalid" does not exist`)
+			case isMySQL():
+				So(merr.Err, ShouldBeError, `Error 1054: Unknown column 'invalid' in 'where clause'`)
+			default:
+				So(merr.Err, ShouldBeError, `no such column: invalid`)
+			}
 		})
 	})
 }
@@ -633,6 +1918,16 @@ func query(db *sq.Database, sql string, args ...interface{}) ([]interface{}, err
 	if err != nil {
 		return nil, err
 	}
+	var types []string
+	if isMySQL() {
+		colTypes, err := rows.ColumnTypes()
+		if err != nil {
+			return nil, err
+		}
+		for _, typ := range colTypes {
+			types = append(types, typ.DatabaseTypeName())
+		}
+	}
 
 	var res []interface{}
 	for rows.Next() {
@@ -648,6 +1943,9 @@ func query(db *sq.Database, sql string, args ...interface{}) ([]interface{}, err
 
 		m := make(map[string]interface{})
 		for i, col := range cols {
+			if types != nil {
+				row[i] = mysqlValue(types[i], row[i])
+			}
 			m[col] = row[i]
 		}
 		res = append(res, m)
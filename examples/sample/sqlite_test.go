@@ -0,0 +1,587 @@
+package test
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/mattn/go-sqlite3"
+	. "github.com/ng-vu/goconveyx"
+	. "github.com/smartystreets/goconvey/convey"
+
+	"github.com/ng-vu/sqlgen/core"
+	sq "github.com/ng-vu/sqlgen/typesafe/sq"
+)
+
+// sqliteDatabases keeps the in-memory databases of connectSQLite alive. An
+// in-memory database is dropped with its last connection, and database/sql
+// discards the connection of a transaction whose context is canceled.
+var (
+	sqliteDatabases []*sql.DB
+	registerSQLite  sync.Once
+)
+
+// connectSQLite connects to a new in-memory database with the schema of the
+// sample. The pool is limited to a single connection, so that transactions do
+// not wait for each other's locks.
+func connectSQLite(opts ...sq.Option) *sq.Database {
+	registerSQLite.Do(func() {
+		// SQLite has no schemas, therefore the schema billing is a database
+		// attached to each connection.
+		sql.Register("sqlite3_sample", &sqlite3.SQLiteDriver{
+			ConnectHook: func(conn *sqlite3.SQLiteConn) error {
+				_, err := conn.Exec(`ATTACH DATABASE 'file:billing?mode=memory&cache=shared' AS billing`, nil)
+				return err
+			},
+		})
+	})
+	connStr := fmt.Sprintf("file:sample%v?mode=memory&cache=shared&_loc=UTC", len(sqliteDatabases))
+	keep, err := sql.Open("sqlite3_sample", connStr)
+	if err == nil {
+		err = keep.Ping()
+	}
+	if err != nil {
+		panic(err)
+	}
+	sqliteDatabases = append(sqliteDatabases, keep)
+
+	opts = append([]sq.Option{sq.SetDialect(sq.SQLite)}, opts...)
+	sdb := sq.MustConnect("sqlite3_sample", connStr, opts...)
+	sdb.DB().SetMaxOpenConns(1)
+	sdb.MustExec(`
+		CREATE TABLE "user" (
+			id         TEXT PRIMARY KEY,
+			name       TEXT,
+			created_at TIMESTAMP,
+			updated_at TIMESTAMP,
+			bool       BOOLEAN,
+			float64    REAL,
+			int        INTEGER,
+			int64      INTEGER,
+			string     TEXT,
+			p_bool     BOOLEAN,
+			p_float64  REAL,
+			p_int      INTEGER,
+			p_int64    INTEGER,
+			p_string   TEXT
+		);
+		CREATE TABLE "user_info" (
+			user_id    TEXT PRIMARY KEY,
+			metadata   TEXT,
+			bool       BOOLEAN,
+			float64    REAL,
+			int        INTEGER,
+			int64      INTEGER,
+			string     TEXT,
+			p_bool     BOOLEAN,
+			p_float64  REAL,
+			p_int      INTEGER,
+			p_int64    INTEGER,
+			p_string   TEXT
+		);
+		CREATE TABLE "complex_info" (
+			id TEXT PRIMARY KEY,
+			address         TEXT,
+			p_address       TEXT,
+			metadata        TEXT,
+			ints            TEXT,
+			int64s          TEXT,
+			strings         TEXT,
+			times           TEXT,
+			times_p         TEXT,
+			alias_string    TEXT,
+			alias_int64     INTEGER,
+			alias_int       INTEGER,
+			alias_bool      BOOLEAN,
+			alias_float64   REAL,
+			alias_p_string  TEXT,
+			alias_p_int64   INTEGER,
+			alias_p_int     INTEGER,
+			alias_p_bool    BOOLEAN,
+			alias_p_float64 REAL
+		);
+		CREATE TABLE "account" (
+			id   TEXT PRIMARY KEY,
+			name TEXT
+		);
+		CREATE TABLE "account_user" (
+			account_id TEXT,
+			user_id    TEXT,
+			role       TEXT,
+			PRIMARY KEY (account_id, user_id)
+		);
+		CREATE TABLE "account_user_permission" (
+			account_id TEXT,
+			user_id    TEXT,
+			permission TEXT
+		);
+		CREATE TABLE "role" (
+			id   TEXT PRIMARY KEY,
+			name TEXT
+		);
+		CREATE TABLE "user_role" (
+			user_id TEXT,
+			role_id TEXT,
+			PRIMARY KEY (user_id, role_id)
+		);
+		CREATE TABLE "setting" (
+			key        TEXT PRIMARY KEY,
+			value      TEXT,
+			created_at TEXT,
+			updated_at TEXT
+		);
+		CREATE TABLE IF NOT EXISTS billing."invoice_v2" (
+			id          TEXT PRIMARY KEY,
+			account_id  TEXT,
+			amount      INTEGER,
+			approved_by TEXT
+		);
+		CREATE TABLE IF NOT EXISTS billing."invoice_role" (
+			invoice_id TEXT,
+			role_id    TEXT,
+			PRIMARY KEY (invoice_id, role_id)
+		);
+		CREATE TABLE "event" (
+			id         INTEGER PRIMARY KEY AUTOINCREMENT,
+			name       TEXT,
+			created_at INTEGER
+		);
+	`)
+	return sdb
+}
+
+type retryableError struct{}
+
+func (retryableError) Error() string   { return "retryable" }
+func (retryableError) Retryable() bool { return true }
+
+func TestSQLite(t *testing.T) {
+	var entries []*sq.LogEntry
+	sdb := connectSQLite(
+		sq.SetLogger(func(entry *sq.LogEntry) { entries = append(entries, entry) }),
+		sq.RetryConfig{MinBackoff: time.Millisecond, MaxBackoff: time.Millisecond},
+	)
+	t0 := time.Date(2020, 10, 11, 8, 9, 10, 123e6, time.UTC)
+
+	Convey("SQLite", t, func() {
+		Reset(func() {
+			sdb.MustExec(`DELETE FROM "user"`)
+			sdb.MustExec(`DELETE FROM "complex_info"`)
+			sdb.MustExec(`DELETE FROM "setting"`)
+			sdb.MustExec(`DELETE FROM "event"`)
+			sdb.MustExec(`DELETE FROM sqlite_sequence`)
+		})
+
+		user := &User{
+			ID:        "1000",
+			Name:      "hello",
+			CreatedAt: t0,
+			UpdatedAt: &t0,
+			Int:       100,
+			PString:   pString("world"),
+		}
+		_, err := sdb.Insert(user)
+		So(err, ShouldBeNil)
+
+		Convey("Get", func() {
+			var item User
+			has, err := sdb.Where("id = ?", "1000").Get(&item)
+			So(err, ShouldBeNil)
+			So(has, ShouldBeTrue)
+			So(&item, ShouldDeepEqual, user)
+		})
+		Convey("Update all: Build", func() {
+			query, _, err := sdb.NewQuery().UpdateAll().Where("id = ?", "1000").BuildUpdate(&Setting{Key: "k"})
+			So(err, ShouldBeNil)
+			So(query, ShouldEqual, `UPDATE "setting" SET ("key","value","created_at","updated_at") = (?,?,?,?) WHERE (id = ?)`)
+		})
+		Convey("Update all", func() {
+			user.Name = "updated"
+			user.PString = nil
+			n, err := sdb.UpdateAll().Where("id = ?", "1000").Update(user)
+			So(err, ShouldBeNil)
+			So(n, ShouldEqual, 1)
+
+			var item User
+			_, err = sdb.Where("id = ?", "1000").Get(&item)
+			So(err, ShouldBeNil)
+			So(item.Name, ShouldEqual, "updated")
+			So(item.PString, ShouldBeNil)
+		})
+		Convey("Offset without limit", func() {
+			_, err := sdb.Insert(&User{ID: "1001"})
+			So(err, ShouldBeNil)
+
+			query, _, err := sdb.OrderBy("id").Offset(1).BuildFind(&Users{})
+			So(err, ShouldBeNil)
+			So(query, ShouldEndWith, `ORDER BY "id" LIMIT -1 OFFSET 1`)
+
+			var items Users
+			So(sdb.OrderBy("id").Offset(1).Find(&items), ShouldBeNil)
+			So(len(items), ShouldEqual, 1)
+			So(items[0].ID, ShouldEqual, "1001")
+		})
+		Convey("Upsert", func() {
+			_, err := sdb.Insert(&Setting{Key: "k1", Value: "v1"})
+			So(err, ShouldBeNil)
+
+			_, err = sdb.Upsert(&Setting{Key: "k1", Value: "v2"})
+			So(err, ShouldBeNil)
+			var item Setting
+			_, err = sdb.Where("key = ?", "k1").Get(&item)
+			So(err, ShouldBeNil)
+			So(item.Value, ShouldEqual, "v2")
+
+			_, err = sdb.DoNothing().Upsert(&Setting{Key: "k1", Value: "v3"})
+			So(err, ShouldBeNil)
+			_, err = sdb.Where("key = ?", "k1").Get(&item)
+			So(err, ShouldBeNil)
+			So(item.Value, ShouldEqual, "v2")
+		})
+		Convey("Arrays and JSON are stored as text", func() {
+			address := Address{Province: "p"}
+			info := &ComplexInfo{
+				ID:       "1000",
+				Address:  address,
+				PAddress: &address,
+				Ints:     []int{1, 2, 3},
+				Strings:  []string{"a", "b"},
+				Times:    []time.Time{t0},
+			}
+			_, err := sdb.Insert(info)
+			So(err, ShouldBeNil)
+
+			var typ, ints string
+			So(sdb.QueryRow("SELECT typeof(ints), ints FROM complex_info").Scan(&typ, &ints), ShouldBeNil)
+			So(typ, ShouldEqual, "text")
+			So(ints, ShouldEqual, "[1,2,3]")
+
+			var item ComplexInfo
+			_, err = sdb.Where("id = ?", "1000").Get(&item)
+			So(err, ShouldBeNil)
+			So(&item, ShouldDeepEqual, info)
+		})
+		Convey("Time", func() {
+			Convey("ISO-8601 text", func() {
+				sdb.MustExec(`INSERT INTO setting (key, created_at) VALUES ('k1', '2020-10-11T08:09:10.123Z')`)
+
+				var item Setting
+				_, err := sdb.Where("key = ?", "k1").Get(&item)
+				So(err, ShouldBeNil)
+				So(item.CreatedAt, ShouldEqual, t0)
+			})
+			Convey("Unix timestamp", func() {
+				sdb.MustExec(`INSERT INTO event (name, created_at) VALUES ('e1', ?)`, t0.Unix())
+
+				var item Event
+				_, err := sdb.Where("name = ?", "e1").Get(&item)
+				So(err, ShouldBeNil)
+				So(item.CreatedAt, ShouldEqual, t0.Truncate(time.Second))
+			})
+			Convey("Invalid text and zero date", func() {
+				tt := core.Time(t0)
+				So(tt.Scan("yesterday"), ShouldBeNil)
+				So(time.Time(tt).IsZero(), ShouldBeTrue)
+
+				tt = core.Time(t0)
+				So(tt.Scan([]byte("0000-00-00 00:00:00")), ShouldBeNil)
+				So(time.Time(tt).IsZero(), ShouldBeTrue)
+			})
+		})
+		Convey("Returning", func() {
+			event := &Event{Name: "e1"}
+			_, err := sdb.Returning().Insert(event)
+			So(err, ShouldBeNil)
+			So(event.ID, ShouldEqual, 1)
+		})
+		Convey("Cursor", func() {
+			_, err := sdb.Insert(&User{ID: "1001"}, &User{ID: "1002"})
+			So(err, ShouldBeNil)
+
+			var items Users
+			So(sdb.OrderBy("id").Limit(2).After("").Find(&items), ShouldBeNil)
+			So(len(items), ShouldEqual, 2)
+
+			cursor, err := sdb.NewQuery().OrderBy("id").Cursor(items[1])
+			So(err, ShouldBeNil)
+			So(sdb.OrderBy("id").Limit(2).After(cursor).Find(&items), ShouldBeNil)
+			So(len(items), ShouldEqual, 1)
+			So(items[0].ID, ShouldEqual, "1002")
+		})
+		Convey("Insert more rows than the parameters of a statement", func() {
+			settings := make(Settings, 10000)
+			for i := range settings {
+				settings[i] = &Setting{Key: fmt.Sprintf("k%v", i), Value: "v"}
+			}
+			n, err := sdb.Insert(settings)
+			So(err, ShouldBeNil)
+			So(n, ShouldEqual, 10000)
+		})
+		Convey("CopyFrom falls back to Insert", func() {
+			n, err := sdb.CopyFrom(context.Background(), Settings{
+				{Key: "k1", Value: "v1"},
+				{Key: "k2", Value: "v2"},
+			})
+			So(err, ShouldBeNil)
+			So(n, ShouldEqual, 2)
+
+			count, err := sdb.Count((*Setting)(nil))
+			So(err, ShouldBeNil)
+			So(count, ShouldEqual, 2)
+		})
+		Convey("Dialect", func() {
+			So(sdb.Opts().Dialect.Name(), ShouldEqual, sq.SQLite.Name())
+		})
+		Convey("InTx", func() {
+			ctx := context.Background()
+			count := func() uint64 {
+				n, err := sdb.Count((*Setting)(nil))
+				So(err, ShouldBeNil)
+				return n
+			}
+
+			Convey("Commit", func() {
+				err := sdb.InTx(ctx, sq.TxOptions{}, func(tx sq.Tx) error {
+					_, err := tx.Insert(&Setting{Key: "k1"})
+					return err
+				})
+				So(err, ShouldBeNil)
+				So(count(), ShouldEqual, 1)
+			})
+			Convey("Rollback on error", func() {
+				err := sdb.InTx(ctx, sq.TxOptions{}, func(tx sq.Tx) error {
+					_, err := tx.Insert(&Setting{Key: "k1"})
+					So(err, ShouldBeNil)
+					return errors.New("failed")
+				})
+				So(err, ShouldBeError, "failed")
+				So(count(), ShouldEqual, 0)
+			})
+			Convey("Rollback on panic", func() {
+				So(func() {
+					_ = sdb.InTx(ctx, sq.TxOptions{}, func(tx sq.Tx) error {
+						_, err := tx.Insert(&Setting{Key: "k1"})
+						So(err, ShouldBeNil)
+						panic("failed")
+					})
+				}, ShouldPanicWith, "failed")
+				So(count(), ShouldEqual, 0)
+			})
+			Convey("Retry", func() {
+				entries = nil
+				attempts := 0
+				err := sdb.InTx(ctx, sq.TxOptions{}, func(tx sq.Tx) error {
+					attempts++
+					if attempts < 3 {
+						return retryableError{}
+					}
+					return nil
+				})
+				So(err, ShouldBeNil)
+				So(attempts, ShouldEqual, 3)
+				So(len(entries), ShouldEqual, 3)
+				So(entries[0].Type(), ShouldEqual, sq.TypeRollback)
+				So(entries[0].Attempt, ShouldEqual, 1)
+				So(entries[1].Type(), ShouldEqual, sq.TypeRollback)
+				So(entries[1].Attempt, ShouldEqual, 2)
+				So(entries[2].Type(), ShouldEqual, sq.TypeCommit)
+				So(entries[2].Attempt, ShouldEqual, 3)
+			})
+			Convey("Give up after MaxAttempts", func() {
+				attempts := 0
+				err := sdb.InTx(ctx, sq.TxOptions{}, func(tx sq.Tx) error {
+					attempts++
+					return retryableError{}
+				})
+				So(err, ShouldHaveSameTypeAs, retryableError{})
+				So(attempts, ShouldEqual, 3)
+			})
+		})
+		Convey("Savepoint", func() {
+			tx, err := sdb.Begin()
+			So(err, ShouldBeNil)
+			_, err = tx.Insert(&Setting{Key: "k1"})
+			So(err, ShouldBeNil)
+
+			sp, err := tx.Begin()
+			So(err, ShouldBeNil)
+			_, err = sp.Insert(&Setting{Key: "k2"})
+			So(err, ShouldBeNil)
+			So(sp.Rollback(), ShouldBeNil)
+			So(sp.Rollback(), ShouldEqual, sql.ErrTxDone)
+
+			sp, err = tx.Begin()
+			So(err, ShouldBeNil)
+			_, err = sp.Insert(&Setting{Key: "k3"})
+			So(err, ShouldBeNil)
+			So(sp.Commit(), ShouldBeNil)
+
+			entries = nil
+			So(tx.Commit(), ShouldBeNil)
+			So(len(entries), ShouldEqual, 1)
+			var queries []string
+			for _, entry := range entries[0].TxQueries {
+				queries = append(queries, entry.Query)
+			}
+			So(queries, ShouldContain, "SAVEPOINT sqlgen_sp1")
+			So(queries, ShouldContain, "ROLLBACK TO SAVEPOINT sqlgen_sp1")
+			So(queries, ShouldContain, "SAVEPOINT sqlgen_sp2")
+			So(queries, ShouldContain, "RELEASE SAVEPOINT sqlgen_sp2")
+
+			var items Settings
+			So(sdb.OrderBy("key").Find(&items), ShouldBeNil)
+			So(len(items), ShouldEqual, 2)
+			So(items[0].Key, ShouldEqual, "k1")
+			So(items[1].Key, ShouldEqual, "k3")
+		})
+		Convey("Callbacks", func() {
+			var calls []string
+			record := func(call string) func() {
+				return func() { calls = append(calls, call) }
+			}
+
+			Convey("Commit", func() {
+				tx, err := sdb.Begin()
+				So(err, ShouldBeNil)
+				tx.OnCommit(record("commit 1"))
+				tx.OnRollback(record("rollback 1"))
+				tx.OnCommit(func() { panic("failed") })
+				tx.OnCommit(record("commit 2"))
+				So(calls, ShouldBeEmpty)
+
+				So(tx.Commit(), ShouldBeNil)
+				So(calls, ShouldResemble, []string{"commit 1", "commit 2"})
+				So(tx.Rollback(), ShouldEqual, sql.ErrTxDone)
+				So(calls, ShouldResemble, []string{"commit 1", "commit 2"})
+			})
+			Convey("Rollback", func() {
+				tx, err := sdb.Begin()
+				So(err, ShouldBeNil)
+				tx.OnCommit(record("commit"))
+				tx.OnRollback(record("rollback"))
+
+				So(tx.Rollback(), ShouldBeNil)
+				So(calls, ShouldResemble, []string{"rollback"})
+			})
+			Convey("Savepoints", func() {
+				tx, err := sdb.Begin()
+				So(err, ShouldBeNil)
+				tx.OnCommit(record("commit tx"))
+
+				sp1, err := tx.Begin()
+				So(err, ShouldBeNil)
+				sp1.OnCommit(record("commit sp1"))
+				sp1.OnRollback(record("rollback sp1"))
+				So(sp1.Rollback(), ShouldBeNil)
+				So(calls, ShouldResemble, []string{"rollback sp1"})
+
+				sp2, err := tx.Begin()
+				So(err, ShouldBeNil)
+				sp2.OnCommit(record("commit sp2"))
+				So(sp2.Commit(), ShouldBeNil)
+				So(calls, ShouldResemble, []string{"rollback sp1"})
+
+				So(tx.Commit(), ShouldBeNil)
+				So(calls, ShouldResemble, []string{"rollback sp1", "commit tx", "commit sp2"})
+			})
+			Convey("InTx", func() {
+				attempts := 0
+				err := sdb.InTx(context.Background(), sq.TxOptions{}, func(tx sq.Tx) error {
+					attempts++
+					tx.OnCommit(record("commit"))
+					tx.OnRollback(record("rollback"))
+					if attempts < 2 {
+						return retryableError{}
+					}
+					return nil
+				})
+				So(err, ShouldBeNil)
+				So(calls, ShouldResemble, []string{"rollback", "commit"})
+			})
+		})
+	})
+}
+
+func TestSQLiteReplicas(t *testing.T) {
+	var replicas []*sql.DB
+	for _, name := range []string{"replica1", "replica2"} {
+		// Named in-memory databases are shared by the connections of the pool.
+		r, err := sql.Open("sqlite3", "file:"+name+"?mode=memory&cache=shared")
+		if err != nil {
+			t.Fatal(err)
+		}
+		r.SetMaxIdleConns(1)
+		if _, err = r.Exec(`CREATE TABLE setting (key TEXT PRIMARY KEY, value TEXT, created_at TEXT, updated_at TEXT)`); err != nil {
+			t.Fatal(err)
+		}
+		if _, err = r.Exec(`INSERT INTO setting (key, value) VALUES ('k1', ?)`, name); err != nil {
+			t.Fatal(err)
+		}
+		replicas = append(replicas, r)
+	}
+	var nodes []string
+	sdb := connectSQLite(
+		sq.ReplicaDBs(replicas...),
+		sq.SetLogger(func(entry *sq.LogEntry) { nodes = append(nodes, entry.Node) }),
+	)
+	_, err := sdb.Insert(&Setting{Key: "k1", Value: "primary"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	Convey("Read replicas", t, func() {
+		nodes = nil
+		get := func(q sq.CommonQuery) string {
+			var item Setting
+			_, err := q.Get(&item, "key = ?", "k1")
+			So(err, ShouldBeNil)
+			return item.Value
+		}
+
+		Convey("Round-robin", func() {
+			So(get(sdb), ShouldEqual, "replica1")
+			So(get(sdb), ShouldEqual, "replica2")
+			So(get(sdb), ShouldEqual, "replica1")
+			So(nodes, ShouldResemble, []string{"replica-1", "replica-2", "replica-1"})
+		})
+		Convey("Count and SELECT", func() {
+			_, err := sdb.Count((*Setting)(nil))
+			So(err, ShouldBeNil)
+			var value string
+			So(sdb.SQL(`SELECT value FROM setting`).Scan(&value), ShouldBeNil)
+			So(nodes, ShouldHaveLength, 2)
+			So(nodes[0], ShouldStartWith, "replica-")
+			So(nodes[1], ShouldStartWith, "replica-")
+		})
+		Convey("UsePrimary", func() {
+			So(get(sdb.UsePrimary()), ShouldEqual, "primary")
+			So(nodes, ShouldResemble, []string{sq.NodePrimary})
+		})
+		Convey("Writes", func() {
+			_, err := sdb.Update(&Setting{Key: "k1", Value: "primary"})
+			So(err, ShouldBeNil)
+			_, err = sdb.SQL(`UPDATE setting SET value = value`).Exec()
+			So(err, ShouldBeNil)
+			So(nodes, ShouldResemble, []string{sq.NodePrimary, sq.NodePrimary})
+		})
+		Convey("Transaction", func() {
+			tx, err := sdb.Begin()
+			So(err, ShouldBeNil)
+			So(get(tx), ShouldEqual, "primary")
+			So(tx.Rollback(), ShouldBeNil)
+			So(nodes, ShouldResemble, []string{sq.NodePrimary, sq.NodePrimary})
+		})
+		Convey("Invalid replica", func() {
+			// The MySQL driver validates the connection string on open.
+			_, err := sq.Connect("mysql", mysqlConnStr, sq.ReplicaDBs(replicas...), sq.Replicas(mysqlConnStr, "invalid"))
+			So(err, ShouldNotBeNil)
+
+			// The databases given by ReplicaDBs are left open.
+			So(replicas[0].Ping(), ShouldBeNil)
+		})
+	})
+}
@@ -39,11 +39,14 @@ type DeclCommon struct {
 	Alias      string
 
 	OptPlural string
+	OptPK     []string
 }
 
+// TableFullName returns the quoted table name, prefixed by the schema name if
+// any.
 func (d DeclCommon) TableFullName() string {
 	if d.SchemaName != "" {
-		return d.SchemaName + ".`" + d.TableName + "`"
+		return d.SchemaName + `."` + d.TableName + `"`
 	}
 	return `"` + d.TableName + `"`
 }
@@ -52,6 +55,9 @@ type Declaration struct {
 	DeclCommon
 	Options Options
 	Joins   Joins
+
+	// Position of the "generate" keyword
+	Pos scanner.Position
 }
 
 func (d *Declaration) String() string {
@@ -86,6 +92,13 @@ func (d *Declaration) ParseOptions() error {
 				return errors.New("Option `plural` already defined")
 			}
 			d.OptPlural = opt.Value
+		case "pk":
+			for _, pk := range d.OptPK {
+				if pk == opt.Value {
+					return fmt.Errorf("Option `pk %v` already defined", opt.Value)
+				}
+			}
+			d.OptPK = append(d.OptPK, opt.Value)
 		default:
 			return fmt.Errorf("Unknown option `%v`", opt.Name)
 		}
@@ -196,6 +209,9 @@ type lexer struct {
 	next string
 	on   bool
 	err  error
+
+	// Positions of the "generate" keywords, one for each declaration
+	pos []scanner.Position
 }
 
 func isKeyword(s string) bool {
@@ -250,13 +266,14 @@ func (l *lexer) Lex(yylval *yySymType) (tok int) {
 	switch text {
 	case "":
 		return 0
-	case ".", ";", "(", ")":
+	case ".", ";", "(", ")", ",":
 		return int(text[0])
 	case "generate":
 		if l.last != 0 && l.last != ';' {
 			l.next = text
 			return ';'
 		}
+		l.pos = append(l.pos, l.Position)
 		return GENERATE
 	case "from":
 		return FROM
@@ -304,5 +321,10 @@ func ParseString(filename, src string) (*RootDeclaration, error) {
 	if yyParse(l) != 0 {
 		return nil, l.err
 	}
+	for i, decl := range result.Declarations {
+		if i < len(l.pos) {
+			decl.Pos = l.pos[i]
+		}
+	}
 	return result, nil
 }
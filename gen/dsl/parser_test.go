@@ -93,6 +93,7 @@ func TestDSL(t *testing.T) {
 		AssertNoError(t, err)
 		AssertEqual(t, file.String(), `generate Account from "schema.account";`+"\n")
 		AssertEqual(t, file.Declarations[0].TableName, `schema.account`)
+		AssertEqual(t, file.Declarations[0].TableFullName(), `"schema.account"`)
 	})
 
 	t.Run("Table name with schema", func(t *testing.T) {
@@ -102,6 +103,7 @@ func TestDSL(t *testing.T) {
 		AssertEqual(t, file.String(), `generate Account from "schema"."account";`+"\n")
 		AssertEqual(t, file.Declarations[0].SchemaName, `schema`)
 		AssertEqual(t, file.Declarations[0].TableName, `account`)
+		AssertEqual(t, file.Declarations[0].TableFullName(), `schema."account"`)
 	})
 
 	t.Run("Table name with schema and quotation", func(t *testing.T) {
@@ -113,6 +115,34 @@ func TestDSL(t *testing.T) {
 		AssertEqual(t, file.Declarations[0].TableName, `account`)
 	})
 
+	t.Run("Primary key option", func(t *testing.T) {
+		src := `generate Account (pk id) from "account"`
+		file, err := ParseString("test", src)
+		AssertNoError(t, err)
+		AssertEqual(t, file.String(), `generate Account (pk id) from "account";`+"\n")
+
+		decl := file.Declarations[0]
+		AssertNoError(t, decl.ParseOptions())
+		AssertEqual(t, decl.OptPK, []string{"id"})
+	})
+
+	t.Run("Composite primary key option", func(t *testing.T) {
+		src := `generate UserInfo (plural UserInfos, pk user_id, pk kind)`
+		file, err := ParseString("test", src)
+		AssertNoError(t, err)
+		AssertEqual(t, file.String(), `generate UserInfo (plural UserInfos, pk user_id, pk kind) from "{}";`+"\n")
+
+		decl := file.Declarations[0]
+		AssertNoError(t, decl.ParseOptions())
+		AssertEqual(t, decl.OptPK, []string{"user_id", "kind"})
+	})
+
+	t.Run("Error: Duplicated primary key option", func(t *testing.T) {
+		file, err := ParseString("test", `generate UserInfo (pk user_id, pk user_id)`)
+		AssertNoError(t, err)
+		AssertErrorEqual(t, file.Declarations[0].ParseOptions(), "Option `pk user_id` already defined")
+	})
+
 	t.Run("Multiple declarations", func(t *testing.T) {
 		src := `
 generate Account from account;
@@ -128,6 +158,17 @@ generate User (plural Users) from "user";
 		AssertEqual(t, len(file.Declarations), 2)
 	})
 
+	t.Run("Declaration positions", func(t *testing.T) {
+		src := `
+generate Account from account;
+  generate User (plural Users) from "user"
+`
+		file, err := ParseString("test.sqlgen", src)
+		AssertNoError(t, err)
+		AssertEqual(t, file.Declarations[0].Pos.String(), "test.sqlgen:2:1")
+		AssertEqual(t, file.Declarations[1].Pos.String(), "test.sqlgen:3:3")
+	})
+
 	t.Run("Auto semicolon insertion", func(t *testing.T) {
 		src := `generate generate Account generate from account`
 		expected := `
@@ -3,9 +3,11 @@ package sqlgen
 import (
 	"fmt"
 	"go/types"
+	"reflect"
 	"strings"
 	"text/template"
 
+	"github.com/ng-vu/sqlgen/gen/dsl"
 	"github.com/ng-vu/sqlgen/gen/strs"
 )
 
@@ -15,11 +17,16 @@ var funcs = template.FuncMap{
 	"quote":     fnQuote,
 	"nonzero":   fnNonZero,
 	"updateArg": fnUpdateArg,
+	"pkArg":     fnPKArg,
+	"valueArg":  fnValueArg,
 	"plural":    fnPlural,
 	"toTitle":   fnToTitle,
 	"typeName":  fnTypeName,
 
+	"columnNames":     fnColumnNames,
 	"tableForType":    fnTableForType,
+	"writeTableName":  fnWriteTableName,
+	"queryTableName":  fnQueryTableName,
 	"listColsForType": fnListColsForType,
 }
 
@@ -41,6 +48,25 @@ func fnQuote(v interface{}) string {
 	return strings.Replace(fmt.Sprintf("%#v", v), `"`, `\"`, -1)
 }
 
+// fnEscape escapes double quotes for writing inside a Go string literal
+func fnEscape(s string) string {
+	return strings.Replace(s, `"`, `\"`, -1)
+}
+
+func fnWriteTableName(schema, table string) string {
+	if schema == "" {
+		return fmt.Sprintf("w.WriteName(%#v)", table)
+	}
+	return fmt.Sprintf("w.WritePrefixedName(%#v, %#v)", schema, table)
+}
+
+func fnQueryTableName(schema, table string) string {
+	if schema == "" {
+		return table
+	}
+	return schema + `."` + table + `"`
+}
+
 func fnTableForType(typ types.Type) string {
 	ts := g.TypeString(typ)
 	if ts[0] == '*' {
@@ -64,6 +90,26 @@ func fnUpdateArg(col *colDef) string {
 	return genUpdateArg(col)
 }
 
+func fnPKArg(col *colDef) string {
+	return genUpdateArg2("m."+col.Path(), col.fieldType, 0)
+}
+
+func fnValueArg(col *colDef) string {
+	res := genInsertArg2("m."+col.Path(), col.fieldType, 0)
+	if nonNilPath := col.GenNonNilPath(); nonNilPath != "" {
+		return "core.Ternary(" + nonNilPath + "," + res + ", nil)"
+	}
+	return res
+}
+
+func fnColumnNames(cols []*colDef) []string {
+	res := make([]string, len(cols))
+	for i, col := range cols {
+		res[i] = col.ColumnName
+	}
+	return res
+}
+
 func fnTypeName(typ types.Type) string {
 	name := g.TypeString(typ)
 	if name[0] == '*' {
@@ -111,6 +157,7 @@ func (g *Gen) GenQueryFor(typ types.Type) error {
 	Str := pStr[1:]
 	Strs := plural(Str)
 	tableName := def.tableName
+	tableFull := dsl.DeclCommon{SchemaName: def.schemaName, TableName: tableName}.TableFullName()
 
 	// generate convert methods
 	if def.base != nil && len(def.joins) == 0 {
@@ -137,6 +184,61 @@ func (g *Gen) GenQueryFor(typ types.Type) error {
 		}
 	}
 
+	// Types without primary key fall back to the ID field
+	var pk *colDef
+	pkFields, mapKeyType, mapKey := []string{"ID"}, "int64", "item.ID"
+	if len(def.pks) != 0 {
+		pkFields = make([]string, len(def.pks))
+		for i, col := range def.pks {
+			pkFields[i] = col.FieldName
+		}
+		mapKeyType, mapKey = Str+"Key", "item.SQLKey()"
+	}
+	if len(def.pks) == 1 {
+		pk = def.pks[0]
+	}
+
+	// Only integer primary keys can be generated by the database
+	pkIsInt := false
+	if pk != nil {
+		desc := GetTypeDesc(pk.fieldType)
+		pkIsInt = !desc.Ptr && desc.Container == 0 &&
+			desc.Elem >= reflect.Int && desc.Elem <= reflect.Uint64
+	}
+
+	if err := g.resolvePreloads(def); err != nil {
+		return err
+	}
+	hasParentKey := false
+	for _, preload := range def.preloads {
+		if !preload.IsBelongsTo() && !preload.IsManyToMany() {
+			hasParentKey = true
+		}
+	}
+
+	var upsertCols, upsertColsNoCreated []*colDef
+	for _, col := range def.cols {
+		if col.pk {
+			continue
+		}
+		upsertCols = append(upsertCols, col)
+		if col.timeLevel != timeCreate {
+			upsertColsNoCreated = append(upsertColsNoCreated, col)
+		}
+	}
+
+	// Columns may be duplicated by embedded structs, the first one is used
+	var valueCols []*colDef
+	for _, col := range def.cols {
+		found := false
+		for _, c := range valueCols {
+			found = found || c.ColumnName == col.ColumnName
+		}
+		if !found {
+			valueCols = append(valueCols, col)
+		}
+	}
+
 	var ptrElems []pathElem
 	for _, s := range def.structs {
 		if s.ptr {
@@ -158,6 +260,8 @@ func (g *Gen) GenQueryFor(typ types.Type) error {
 		"TypeName":  Str,
 		"TypeNames": Strs,
 		"TableName": tableName,
+		"TableFull": fnEscape(tableFull),
+		"Schema":    def.schemaName,
 		"Cols":      def.cols,
 		"ColsList":  listColumns("", def.cols),
 		"QueryArgs": listInsertArgs(def.cols),
@@ -165,25 +269,40 @@ func (g *Gen) GenQueryFor(typ types.Type) error {
 		"NumJoins":  len(def.joins),
 		"PtrElems":  ptrElems,
 		"ScanArgs":  listScanArgs(def.cols),
+		"ValueCols": valueCols,
 		"TimeLevel": def.timeLevel,
 
+		"UpsertCols":          upsertCols,
+		"UpsertColsNoCreated": upsertColsNoCreated,
+		"HasCreated":          len(upsertCols) != len(upsertColsNoCreated),
+
 		"As":        def.as,
 		"Joins":     def.joins,
 		"JoinTypes": joinTypes,
 		"JoinAs":    joinAs,
 		"JoinConds": joinConds,
 
-		"Preloads": def.preloads,
+		"Preloads":     def.preloads,
+		"HasParentKey": hasParentKey,
+
+		"PK":         pk,
+		"PKIsInt":    pkIsInt,
+		"PKs":        def.pks,
+		"PKFields":   pkFields,
+		"KeyType":    Str + "Key",
+		"MapKeyType": mapKeyType,
+		"MapKey":     mapKey,
 
 		"_ListCols":  fmt.Sprintf("__sql%v_ListCols", Str),
 		"_Table":     fmt.Sprintf("__sql%v_Table", Str),
 		"_Insert":    fmt.Sprintf("__sql%v_Insert", Str),
 		"_Select":    fmt.Sprintf("__sql%v_Select", Str),
-		"_UpdateAll": fmt.Sprintf("__sql%v_UpdateAll", Str),
 		"_JoinTypes": fmt.Sprintf("__sql%v_JoinTypes", Str),
 		"_Join":      fmt.Sprintf("__sql%v_Join", Str),
 		"_JoinConds": fmt.Sprintf("__sql%v_JoinConds", Str),
 		"_As":        fmt.Sprintf("__sql%v_As", Str),
+		"_PK":        fmt.Sprintf("__sql%v_PK", Str),
+		"_Cols":      fmt.Sprintf("__sql%v_Cols", Str),
 		"_JoinAs":    fmt.Sprintf("__sql%v_JoinAs", Str),
 	}
 
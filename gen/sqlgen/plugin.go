@@ -55,10 +55,12 @@ type typeDef struct {
 	cols     []*colDef
 	joins    []*joinDef
 	preloads []*preloadDef
+	pks      []*colDef
 
-	tableName string
-	as        string
-	structs   pathElems
+	schemaName string
+	tableName  string
+	as         string
+	structs    pathElems
 
 	all    bool
 	selecT bool
@@ -78,8 +80,11 @@ type colDef struct {
 	columnType string
 	timeLevel  timeLevel
 	fkey       string
+	through    string
+	ref        string
 	pathElems
 
+	pk          bool
 	exclude     bool
 	_nonNilPath string
 }
@@ -104,6 +109,10 @@ func genNonNilPath(prefix string, path pathElems) string {
 	return v[:len(v)-4] // remove the last " && "
 }
 
+func (c *colDef) GoType() string {
+	return c.columnType
+}
+
 func (c *colDef) String() string {
 	return c.FieldName
 }
@@ -177,13 +186,125 @@ type joinDef struct {
 	JoinDef  *dsl.Join
 }
 
+type preloadKind int
+
+const (
+	preloadHasMany preloadKind = iota
+	preloadHasOne
+	preloadBelongsTo
+	preloadManyToMany
+)
+
 type preloadDef struct {
 	FieldType     types.Type
 	FieldName     string
 	TableName     string
+	TypeStr       string
 	PluralTypeStr string
 	BaseType      types.Type
 	Fkey          string
+	Fkeys         []string
+
+	// The expression for looking up the parent from a preloaded item
+	ItemKey string
+
+	kind preloadKind
+
+	// Belongs-to: the columns of the parent referencing the preloaded type,
+	// the key type and the key expression of a preloaded item
+	refCols      []*colDef
+	refKeyFields []string
+	RefKeyType   string
+	RefItemKey   string
+
+	// Many-to-many: the link table, its column referencing the preloaded type
+	// and the key column of the preloaded type. The link table references the
+	// parent by Fkey.
+	Through     string
+	Ref         string
+	RefKey      string
+	ParentKey   *colDef
+	RefKeyField string
+}
+
+func (p *preloadDef) IsSlice() bool {
+	return p.kind == preloadHasMany
+}
+
+func (p *preloadDef) IsBelongsTo() bool {
+	return p.kind == preloadBelongsTo
+}
+
+func (p *preloadDef) IsManyToMany() bool {
+	return p.kind == preloadManyToMany
+}
+
+// AppendRefIDs generates the code for appending the references of v to ids,
+// skipping nil references.
+func (p *preloadDef) AppendRefIDs(v string) string {
+	b := make([]byte, 0, 64)
+	b = appends(b, "ids = append(ids")
+	for _, col := range p.refCols {
+		b = appends(b, ", ", refExpr(v, col))
+	}
+	b = append(b, ')')
+	return wrapRefCond(p.refCols, v, string(b))
+}
+
+// PopulateRef generates the code for populating v from mapItem.
+func (p *preloadDef) PopulateRef(v string) string {
+	var key string
+	if len(p.refCols) == 1 {
+		key = refExpr(v, p.refCols[0])
+	} else {
+		b := make([]byte, 0, 64)
+		b = appends(b, p.RefKeyType, "{")
+		for i, col := range p.refCols {
+			if i > 0 {
+				b = append(b, ", "...)
+			}
+			b = appends(b, p.refKeyFields[i], ": ", refExpr(v, col))
+		}
+		b = append(b, '}')
+		key = string(b)
+	}
+	return wrapRefCond(p.refCols, v, v+"."+p.FieldName+" = mapItem["+key+"]")
+}
+
+func refExpr(v string, col *colDef) string {
+	if GetTypeDesc(col.fieldType).Ptr {
+		return "*" + v + "." + col.Path()
+	}
+	return v + "." + col.Path()
+}
+
+func wrapRefCond(cols []*colDef, v string, stmt string) string {
+	var conds []string
+	for _, col := range cols {
+		if GetTypeDesc(col.fieldType).Ptr {
+			conds = append(conds, v+"."+col.Path()+" != nil")
+		}
+	}
+	if len(conds) == 0 {
+		return stmt
+	}
+	return "if " + strings.Join(conds, " && ") + " {\n" + stmt + "\n}"
+}
+
+func genItemKey(typeName string, pks []*colDef, fkeys []string) string {
+	if len(pks) == 0 {
+		return "item." + fnToTitle(fkeys[0])
+	}
+	b := make([]byte, 0, 64)
+	b = appends(b, typeName, "Key{")
+	for i, pk := range pks {
+		if i > 0 {
+			b = append(b, ", "...)
+		}
+		b = appends(b, pk.FieldName, ": item.", fnToTitle(fkeys[i]))
+	}
+	b = append(b, '}')
+	return string(b)
 }
 
 func (g *Gen) Add(getTypeForStruct func(name string) types.Type, name string, typ types.Type, decl *dsl.Declaration) error {
@@ -197,29 +318,56 @@ func (g *Gen) Add(getTypeForStruct func(name string) types.Type, name string, ty
 		return err
 	}
 
+	pks, err := parsePrimaryKey(cols, decl)
+	if err != nil {
+		return err
+	}
+
+	typeName := bareTypeName(typ)
 	preloads := make([]*preloadDef, len(excols))
 	for i, col := range excols {
 		typ := col.fieldType
 		desc := GetTypeDesc(typ)
-		if !desc.Ptr && desc.Container == reflect.Slice &&
-			desc.PtrElem && desc.Elem == reflect.Struct {
-			// continue
-		} else {
-			return fmt.Errorf("Preload type must be slice of pointer to struct (got %v)", desc.TypeString)
-		}
+		var kind preloadKind
+		var bareTypeStr string
+		switch {
+		case !desc.Ptr && desc.Container == reflect.Slice &&
+			desc.PtrElem && desc.Elem == reflect.Struct:
+			if !strings.HasPrefix(desc.TypeString, "[]*") {
+				return fmt.Errorf("Only support []* for preload type")
+			}
+			kind, bareTypeStr = preloadHasMany, desc.TypeString[3:]
+			typ = typ.Underlying().(*types.Slice).Elem()
+			if col.through != "" {
+				kind = preloadManyToMany
+			}
+
+		case desc.Ptr && desc.Container == 0 && desc.Elem == reflect.Struct:
+			if col.through != "" {
+				return fmt.Errorf("Preload through a link table must be slice of pointer to struct (at `%v`.%v)", typeName, col.FieldName)
+			}
+			if !strings.HasPrefix(desc.TypeString, "*") {
+				return fmt.Errorf("Only support * for preload type")
+			}
+			// Either has-one or belongs-to, which is resolved later
+			kind, bareTypeStr = preloadHasOne, desc.TypeString[1:]
 
-		if !strings.HasPrefix(desc.TypeString, "[]*") {
-			return fmt.Errorf("Only support []* for preload type")
+		default:
+			return fmt.Errorf("Preload type must be slice of pointer or pointer to struct (got %v)", desc.TypeString)
 		}
-		bareTypeStr := desc.TypeString[3:]
 
 		preload := &preloadDef{
 			TableName:     toSnake(bareTypeStr),
 			FieldType:     col.fieldType,
 			FieldName:     col.FieldName,
+			TypeStr:       bareTypeStr,
 			PluralTypeStr: plural(bareTypeStr),
-			BaseType:      nil, // TODO
+			BaseType:      typ.(*types.Pointer).Elem(),
 			Fkey:          col.fkey,
+			Fkeys:         strings.Split(col.fkey, ","),
+			Through:       col.through,
+			Ref:           col.ref,
+			kind:          kind,
 		}
 		preloads[i] = preload
 	}
@@ -228,6 +376,7 @@ func (g *Gen) Add(getTypeForStruct func(name string) types.Type, name string, ty
 		typ:      typ,
 		all:      true,
 		cols:     cols,
+		pks:      pks,
 		preloads: preloads,
 		structs:  getStructsFromCols(cols),
 	}
@@ -242,6 +391,9 @@ func (g *Gen) Add(getTypeForStruct func(name string) types.Type, name string, ty
 	g.mapBase[typ.String()] = true
 
 	if len(decl.Joins) != 0 {
+		if len(def.pks) != 0 {
+			return fmt.Errorf("Primary key can not be declared on join type %v", decl.StructName)
+		}
 		def.base = getTypeForStruct(decl.Joins[0].StructName)
 		def.all = false
 		def.as = decl.Joins[0].Alias
@@ -257,15 +409,216 @@ func (g *Gen) Add(getTypeForStruct func(name string) types.Type, name string, ty
 		def.joins = joins
 	}
 
+	// Use the table name from the declaration, the first join is the FROM
+	// table of join types.
 	if def.base != nil {
-		def.tableName = strs.ToSnake(bareTypeName(def.base))
+		def.schemaName = decl.Joins[0].SchemaName
+		def.tableName = decl.Joins[0].TableName
+		if def.tableName == "" {
+			def.tableName = strs.ToSnake(bareTypeName(def.base))
+		}
 	} else {
-		def.tableName = strs.ToSnake(bareTypeName(typ))
+		def.schemaName = decl.SchemaName
+		def.tableName = decl.TableName
+		if def.tableName == "" {
+			def.tableName = strs.ToSnake(bareTypeName(typ))
+		}
 	}
 	g.mapType[typ.String()] = def
 	return nil
 }
 
+// resolvePreloads validates the preloads of def against the declared preloaded
+// types. A pointer preload is belongs-to when the parent has the fkey columns,
+// otherwise it is has-one. It must be called after all types are added.
+func (g *Gen) resolvePreloads(def *typeDef) error {
+	typeName := bareTypeName(def.typ)
+	tables := make(map[string]bool)
+	for _, preload := range def.preloads {
+		child := g.mapType[preload.BaseType.String()]
+		if child == nil {
+			child = g.mapType["*"+preload.BaseType.String()]
+		}
+		if child == nil {
+			return fmt.Errorf("Preload type %v must be declared (at `%v`.%v)", preload.TypeStr, typeName, preload.FieldName)
+		}
+		preload.TableName = g.tableName(child)
+		if tables[preload.TableName] {
+			return fmt.Errorf("Duplicated preload table %v (at `%v`.%v)", preload.TableName, typeName, preload.FieldName)
+		}
+		tables[preload.TableName] = true
+
+		if preload.kind == preloadHasOne {
+			refCols := findColumns(def.cols, preload.Fkeys)
+			if refCols != nil {
+				preload.kind = preloadBelongsTo
+				preload.refCols = refCols
+			}
+		}
+		switch preload.kind {
+		case preloadBelongsTo:
+			if err := resolveBelongsTo(preload, child, typeName); err != nil {
+				return err
+			}
+			continue
+		case preloadManyToMany:
+			if err := resolveManyToMany(preload, def, child, typeName); err != nil {
+				return err
+			}
+			continue
+		}
+
+		pks, fkeys := def.pks, preload.Fkeys
+		if len(pks) == 0 && len(fkeys) > 1 {
+			return fmt.Errorf("Preload with composite fkey requires a composite primary key (at `%v`.%v)", typeName, preload.FieldName)
+		}
+		if len(pks) != 0 && len(fkeys) != len(pks) {
+			return fmt.Errorf("Preload fkey must have %v %v (at `%v`.%v)", len(pks), fnPlural(len(pks), "column"), typeName, preload.FieldName)
+		}
+		for _, fkey := range fkeys {
+			if findColumns(child.cols, []string{fkey}) == nil {
+				return fmt.Errorf("Preload fkey `%v` not found in type %v (at `%v`.%v)", fkey, preload.TypeStr, typeName, preload.FieldName)
+			}
+		}
+		preload.ItemKey = genItemKey(typeName, pks, fkeys)
+	}
+	return nil
+}
+
+// resolveBelongsTo looks up the preloaded items by their primary key, or the
+// id column when the preloaded type has no primary key.
+func resolveBelongsTo(preload *preloadDef, child *typeDef, typeName string) error {
+	pks := child.pks
+	if len(pks) == 0 {
+		pks = findColumns(child.cols, []string{"id"})
+	}
+	if len(pks) == 0 {
+		return fmt.Errorf("Preload type %v must have a primary key (at `%v`.%v)", preload.TypeStr, typeName, preload.FieldName)
+	}
+	if len(pks) != len(preload.refCols) {
+		return fmt.Errorf("Preload fkey must have %v %v (at `%v`.%v)", len(pks), fnPlural(len(pks), "column"), typeName, preload.FieldName)
+	}
+
+	cols := make([]string, len(pks))
+	fields := make([]string, len(pks))
+	for i, pk := range pks {
+		refType := preload.refCols[i].GoType()
+		if GetTypeDesc(preload.refCols[i].fieldType).Ptr {
+			refType = refType[1:]
+		}
+		if refType != pk.GoType() {
+			return fmt.Errorf("Preload fkey `%v` must have type %v or *%v (at `%v`.%v)", preload.Fkeys[i], pk.GoType(), pk.GoType(), typeName, preload.FieldName)
+		}
+		cols[i], fields[i] = pk.ColumnName, pk.FieldName
+	}
+	preload.Fkey, preload.Fkeys = strings.Join(cols, ","), cols
+	preload.refKeyFields = fields
+	if len(pks) == 1 {
+		preload.RefKeyType = pks[0].GoType()
+		preload.RefItemKey = "item." + pks[0].FieldName
+	} else {
+		preload.RefKeyType = preload.TypeStr + "Key"
+		preload.RefItemKey = "item.SQLKey()"
+	}
+	return nil
+}
+
+// resolveManyToMany links the parent and the preloaded items by their single
+// primary key, or the id column when there is no primary key.
+func resolveManyToMany(preload *preloadDef, def, child *typeDef, typeName string) error {
+	if len(preload.Fkeys) != 1 || strings.Contains(preload.Ref, ",") {
+		return fmt.Errorf("Preload through a link table does not support composite key (at `%v`.%v)", typeName, preload.FieldName)
+	}
+	parentKey := keyColumn(def)
+	if parentKey == nil {
+		return fmt.Errorf("Type %v must have a single primary key for preload through a link table (at `%v`.%v)", typeName, typeName, preload.FieldName)
+	}
+	refKey := keyColumn(child)
+	if refKey == nil {
+		return fmt.Errorf("Preload type %v must have a single primary key (at `%v`.%v)", preload.TypeStr, typeName, preload.FieldName)
+	}
+	preload.ParentKey = parentKey
+	preload.RefKey = refKey.ColumnName
+	preload.RefKeyType = refKey.GoType()
+	preload.RefKeyField = refKey.FieldName
+	return nil
+}
+
+// keyColumn returns the single primary key of def, or the id column when def
+// has no primary key.
+func keyColumn(def *typeDef) *colDef {
+	switch len(def.pks) {
+	case 0:
+		if cols := findColumns(def.cols, []string{"id"}); cols != nil {
+			return cols[0]
+		}
+		return nil
+	case 1:
+		return def.pks[0]
+	default:
+		return nil
+	}
+}
+
+// findColumns returns the columns with the given names, or nil if any of them
+// is not found.
+func findColumns(cols []*colDef, names []string) []*colDef {
+	res := make([]*colDef, len(names))
+	for i, name := range names {
+		for _, col := range cols {
+			if col.ColumnName == name {
+				res[i] = col
+				break
+			}
+		}
+		if res[i] == nil {
+			return nil
+		}
+	}
+	return res
+}
+
+// parsePrimaryKey returns the primary key columns, which are declared by either
+// the `pk` tag or the `(pk column)` options. A composite key is declared by
+// multiple `pk` tags or options.
+func parsePrimaryKey(cols []*colDef, decl *dsl.Declaration) ([]*colDef, error) {
+	var pks []*colDef
+	for _, col := range cols {
+		if col.pk {
+			pks = append(pks, col)
+		}
+	}
+	if len(decl.OptPK) != 0 {
+		if len(pks) != 0 {
+			return nil, fmt.Errorf("Primary key of type %v must be declared by either tag or option", decl.StructName)
+		}
+		for _, name := range decl.OptPK {
+			var pk *colDef
+			for _, col := range cols {
+				if col.ColumnName == name {
+					pk = col
+					break
+				}
+			}
+			if pk == nil {
+				return nil, fmt.Errorf("Primary key `%v` not found in type %v", name, decl.StructName)
+			}
+			if len(pk.pathElems) > 1 {
+				return nil, fmt.Errorf("Primary key `%v` can not be a column of inline struct", name)
+			}
+			pk.pk = true
+			pks = append(pks, pk)
+		}
+	}
+	for _, pk := range pks {
+		desc := GetTypeDesc(pk.fieldType)
+		if !(desc.IsBasic() && !desc.Ptr) && !desc.IsBareTime() {
+			return nil, fmt.Errorf("Primary key `%v` must be a basic type or time.Time (got %v)", pk.ColumnName, desc.TypeString)
+		}
+	}
+	return pks, nil
+}
+
 func (g *Gen) validateTypes() error {
 	for _, def := range g.mapType {
 		if def.base != nil {
@@ -285,7 +638,7 @@ var (
 	reTagColumnName = regexp.MustCompile(`'[0-9A-Za-z._-]+'`)
 	reTagKeyword    = regexp.MustCompile(`\b[a-z]+\b`)
 	reTagSpaces     = regexp.MustCompile(`^\s*$`)
-	reTagPreload    = regexp.MustCompile(`^preload,fkey:'([0-9A-Za-z._-]+)'$`)
+	reTagPreloadOpt = regexp.MustCompile(`^,([a-z]+):'([0-9A-Za-z._-]+(?:,[0-9A-Za-z._-]+)*)'`)
 )
 
 func (g *Gen) parseColumnsFromType(path pathElems, root types.Type, sTyp *types.Struct) ([]*colDef, []*colDef, error) {
@@ -314,17 +667,17 @@ func (g *Gen) parseColumnsFromType(path pathElems, root types.Type, sTyp *types.
 
 		columnName := toSnake(field.Name())
 		columnType := g.TypeString(field.Type())
-		inline, create, update := false, false, false
-		var fkey string
+		inline, create, update, pk := false, false, false, false
+		var fkey, through, ref string
 		if tag != "" {
 			ntag := tag
 			if strings.HasPrefix(ntag, "preload") {
-				parts := reTagPreload.FindStringSubmatch(ntag)
-				if len(parts) == 0 {
-					return nil, nil, fmt.Errorf("`preload` tag must have format \"preload,fkey:'<column>'\" (Did you forget the single quote?)")
+				var err error
+				fkey, through, ref, err = parsePreloadTag(ntag)
+				if err != nil {
+					return nil, nil, fmt.Errorf("%v (at `%v`.%v)", err, g.TypeString(root), fieldPath)
 				}
 				tag = "preload"
-				fkey = parts[1]
 				goto endparse
 			}
 			if s := reTagColumnName.FindString(ntag); s != "" {
@@ -336,6 +689,8 @@ func (g *Gen) parseColumnsFromType(path pathElems, root types.Type, sTyp *types.
 				switch keyword {
 				case "inline":
 					inline = true
+				case "pk":
+					pk = true
 				case "create", "created":
 					create = true
 					if columnType != "time.Time" && columnType != "*time.Time" {
@@ -348,7 +703,7 @@ func (g *Gen) parseColumnsFromType(path pathElems, root types.Type, sTyp *types.
 					}
 				default:
 					return nil, nil, fmt.Errorf(
-						"Unregconized keyword `%v` at `%v`.%v",
+						"Unrecognized keyword `%v` at `%v`.%v",
 						keyword, g.TypeString(root), fieldPath)
 				}
 				ntag = strings.Replace(ntag, keyword, "", -1)
@@ -364,6 +719,10 @@ func (g *Gen) parseColumnsFromType(path pathElems, root types.Type, sTyp *types.
 			return nil, nil, fmt.Errorf(
 				"`inline`, `create`, `update` flags can not be used together (at `%v`.%v)", g.TypeString(root), fieldPath)
 		}
+		if pk && (inline || len(fieldPath) > 1) {
+			return nil, nil, fmt.Errorf(
+				"`pk` flag can not be used on inline struct (at `%v`.%v)", g.TypeString(root), fieldPath)
+		}
 		if inline {
 			typ := field.Type()
 			if t, ok := typ.Underlying().(*types.Pointer); ok {
@@ -391,6 +750,9 @@ func (g *Gen) parseColumnsFromType(path pathElems, root types.Type, sTyp *types.
 			columnType: columnType,
 			pathElems:  fieldPath,
 			fkey:       fkey,
+			through:    through,
+			ref:        ref,
+			pk:         pk,
 			exclude:    tag == "preload",
 		}
 		if create {
@@ -407,6 +769,42 @@ func (g *Gen) parseColumnsFromType(path pathElems, root types.Type, sTyp *types.
 	return cols, excols, nil
 }
 
+// parsePreloadTag parses the options of the `preload` tag, which has format
+// "preload,fkey:'<column>'" or "preload,through:'<table>',fkey:'<column>',ref:'<column>'".
+func parsePreloadTag(tag string) (fkey, through, ref string, err error) {
+	const format = "`preload` tag must have format \"preload,fkey:'<column>'\" or \"preload,through:'<table>',fkey:'<column>',ref:'<column>'\""
+	s := tag[len("preload"):]
+	for s != "" {
+		parts := reTagPreloadOpt.FindStringSubmatch(s)
+		if len(parts) == 0 {
+			return "", "", "", fmt.Errorf("%v (Did you forget the single quote?)", format)
+		}
+		var opt *string
+		switch parts[1] {
+		case "fkey":
+			opt = &fkey
+		case "through":
+			opt = &through
+		case "ref":
+			opt = &ref
+		default:
+			return "", "", "", fmt.Errorf("Unrecognized option `%v` in `preload` tag", parts[1])
+		}
+		if *opt != "" {
+			return "", "", "", fmt.Errorf("Duplicated option `%v` in `preload` tag", parts[1])
+		}
+		*opt = parts[2]
+		s = s[len(parts[0]):]
+	}
+	switch {
+	case fkey == "":
+		return "", "", "", fmt.Errorf("%v (missing fkey)", format)
+	case (through == "") != (ref == ""):
+		return "", "", "", fmt.Errorf("`through` and `ref` must be used together in `preload` tag")
+	}
+	return fkey, through, ref, nil
+}
+
 func getStructsFromCols(cols []*colDef) (res []pathElem) {
 	cpath := ""
 	for _, col := range cols {
@@ -457,6 +855,9 @@ func listScanArgs(cols []*colDef) []string {
 }
 
 func (g *Gen) tableName(def *typeDef) string {
+	if def.tableName != "" {
+		return def.tableName
+	}
 	typ := def.typ
 	if def.base != nil {
 		typ = def.base
@@ -465,8 +866,13 @@ func (g *Gen) tableName(def *typeDef) string {
 	return toSnake(name)
 }
 
+// tableNameOf returns the declared table name of typ, or an empty string if
+// the type is not declared.
 func (g *Gen) tableNameOf(typ types.Type) string {
 	def := g.mapType[typ.String()]
+	if def == nil {
+		return ""
+	}
 	return g.tableName(def)
 }
 
@@ -6,10 +6,9 @@ type {{.TypeNames}} []*{{.TypeName}}
 {{if .IsSimple}}
 const {{._Table}} = {{.TableName | go}}
 const {{._ListCols}} = {{.ColsList | go}}
-const {{._Insert}} = "INSERT INTO {{.TableName | quote}} (" + {{._ListCols}} + ") VALUES"
-const {{._Select}} = "SELECT " + {{._ListCols}} + " FROM {{.TableName | quote}}"
+const {{._Insert}} = "INSERT INTO {{.TableFull}} (" + {{._ListCols}} + ") VALUES"
+const {{._Select}} = "SELECT " + {{._ListCols}} + " FROM {{.TableFull}}"
 const {{._Select}}_history = "SELECT " + {{._ListCols}} + " FROM history.{{.TableName | quote}}"
-const {{._UpdateAll}} = "UPDATE {{.TableName | quote}} SET (" + {{._ListCols}} + ")"
 {{else}}
 var {{._JoinTypes}} = []sq.JOIN_TYPE{ {{.JoinTypes | join}} }
 var {{._As}} sq.AS = "{{.As}}"
@@ -19,6 +18,15 @@ var {{._JoinConds}} = []string{ {{.JoinConds | join}} }
 
 func (m *{{.TypeName}}) SQLTableName() string { return {{.TableName | go}} }
 func (m {{.TypeNames}}) SQLTableName() string { return {{.TableName | go}} }
+{{if .IsSimple}}
+var {{._Cols}} = {{.Cols | columnNames | go}}
+
+func (_ *{{.TypeName}}) SQLColumns() []string { return {{._Cols}} }
+func (_ {{.TypeNames}}) SQLColumns() []string { return {{._Cols}} }
+{{end}}{{if .Schema}}
+func (m *{{.TypeName}}) SQLSchemaName() string { return {{.Schema | go}} }
+func (m {{.TypeNames}}) SQLSchemaName() string { return {{.Schema | go}} }
+{{end}}
 
 {{if or .IsAll .IsInsert .IsUpdate}}
 func (m *{{.TypeName}}) SQLArgs(opts core.Opts, create bool) []interface{} {
@@ -44,6 +52,16 @@ func (m *{{.TypeName}}) SQLScanArgs(opts core.Opts) []interface{} {
 		{{end -}}
 	}
 }
+
+func (m *{{.TypeName}}) SQLColumnValue(opts core.Opts, col string) (interface{}, bool) {
+	switch col {
+	{{range .ValueCols -}}
+	case {{.ColumnName | go}}:
+		return {{valueArg .}}, true
+	{{end -}}
+	}
+	return nil, false
+}
 {{end}}
 
 {{if or .IsAll .IsSelect .IsJoin}}
@@ -102,6 +120,53 @@ func (ms {{.TypeNames}}) SQLInsert(w SQLWriter) error {
 	w.TrimLast(2)
 	return nil
 }
+
+func (m *{{.TypeName}}) SQLUpsert(w SQLWriter, conflictCols []string, updateCols []string) error {
+	{{if .PKs -}}
+	if len(conflictCols) == 0 {
+		conflictCols = {{._PK}}
+	}
+	{{else -}}
+	if len(conflictCols) == 0 {
+		return core.Errorf("sqlgen: upsert {{.TypeName}} without primary key requires OnConflict columns")
+	}
+	{{end -}}
+	if err := m.SQLInsert(w); err != nil {
+		return err
+	}
+	w.WriteOnConflict(conflictCols, updateCols)
+	return nil
+}
+
+func (ms {{.TypeNames}}) SQLUpsert(w SQLWriter, conflictCols []string, updateCols []string) error {
+	{{if .PKs -}}
+	if len(conflictCols) == 0 {
+		conflictCols = {{._PK}}
+	}
+	{{else -}}
+	if len(conflictCols) == 0 {
+		return core.Errorf("sqlgen: upsert {{.TypeName}} without primary key requires OnConflict columns")
+	}
+	{{end -}}
+	if err := ms.SQLInsert(w); err != nil {
+		return err
+	}
+	w.WriteOnConflict(conflictCols, updateCols)
+	return nil
+}
+
+func (_ *{{.TypeName}}) SQLUpsertColumns(skipCreated bool) []string {
+	{{if .HasCreated -}}
+	if skipCreated {
+		return {{.UpsertColsNoCreated | columnNames | go}}
+	}
+	{{end -}}
+	return {{.UpsertCols | columnNames | go}}
+}
+
+func (_ {{.TypeNames}}) SQLUpsertColumns(skipCreated bool) []string {
+	return (*{{.TypeName}})(nil).SQLUpsertColumns(skipCreated)
+}
 {{end}}
 
 {{if or .IsAll .IsUpdate}}
@@ -110,7 +175,7 @@ func (m *{{.TypeName}}) SQLUpdate(w SQLWriter) error {
 	_, _ = now, opts // suppress unused error
 	var flag bool
 	w.WriteRawString("UPDATE ")
-	w.WriteName({{.TableName | go}})
+	{{writeTableName .Schema .TableName}}
 	w.WriteRawString(" SET ")
 	{{range .Cols -}}
 	if {{nonzero .}} {
@@ -130,15 +195,91 @@ func (m *{{.TypeName}}) SQLUpdate(w SQLWriter) error {
 }
 
 func (m *{{.TypeName}}) SQLUpdateAll(w SQLWriter) error {
-	w.WriteQueryString({{._UpdateAll}})
-	w.WriteRawString(" = (")
-	w.WriteMarkers({{.NumCols}})
-	w.WriteByte(')')
+	w.WriteRawString("UPDATE ")
+	{{writeTableName .Schema .TableName}}
+	w.WriteRawString(" SET ")
+	w.WriteSetColumns({{._Cols}})
 	w.WriteArgs(m.SQLArgs(w.Opts(), false))
 	return nil
 }
 {{end}}
 
+{{if .PKs}}
+var {{._PK}} = {{.PKs | columnNames | go}}
+
+type {{.KeyType}} struct {
+	{{range .PKs -}}
+	{{.FieldName}} {{.GoType}}
+	{{end -}}
+}
+
+func (m *{{.TypeName}}) SQLKey() {{.KeyType}} {
+	return {{.KeyType}}{
+		{{range .PKs -}}
+		{{.FieldName}}: m.{{.FieldName}},
+		{{end -}}
+	}
+}
+
+{{if or .IsAll .IsUpdate -}}
+func (_ *{{.TypeName}}) SQLPrimaryKeyColumns() []string { return {{._PK}} }
+{{- end}}
+
+func (m *{{.TypeName}}) SQLPrimaryKey(w SQLWriter) error {
+	{{range .PKs -}}
+	if !({{nonzero .}}) {
+		return core.InvalidArgumentError("missing {{.ColumnName}}")
+	}
+	{{end -}}
+	{{range $i, $pk := .PKs -}}
+	{{if $i -}}
+	w.WriteRawString(" AND ")
+	{{end -}}
+	w.WriteName({{.ColumnName | go}})
+	w.WriteRawString(" = ")
+	w.WriteMarker()
+	w.WriteArg({{pkArg .}})
+	{{end -}}
+	return nil
+}
+
+{{if .PKIsInt -}}
+func (m *{{.TypeName}}) SQLSetLastInsertID(id int64) {
+	m.{{.PK.FieldName}} = {{.PK.GoType}}(id)
+}
+{{end}}
+
+{{if .PK -}}
+func (m *{{.TypeName}}) GetByPK(q sq.CommonQuery, pk {{.PK.GoType}}) (bool, error) {
+	m.{{.PK.FieldName}} = pk
+	return q.Get(m, sq.WriterToFunc(m.SQLPrimaryKey))
+}
+{{- end}}
+
+func (m *{{.TypeName}}) GetByKey(q sq.CommonQuery, key {{.KeyType}}) (bool, error) {
+	{{range .PKs -}}
+	m.{{.FieldName}} = key.{{.FieldName}}
+	{{end -}}
+	return q.Get(m, sq.WriterToFunc(m.SQLPrimaryKey))
+}
+
+func (ms *{{.TypeNames}}) FindByKeys(q sq.CommonQuery, keys ...{{.KeyType}}) error {
+	args := make([]interface{}, 0, len(keys)*{{len .PKs}})
+	for _, key := range keys {
+		args = append(args{{range .PKs}}, key.{{.FieldName}}{{end}})
+	}
+	return q.Where(sq.Ins({{._PK}}, args...)).Find(ms)
+}
+
+func (m *{{.TypeName}}) UpdateByPK(q sq.CommonQuery) (int64, error) {
+	return q.Where(sq.WriterToFunc(m.SQLPrimaryKey)).Update(m)
+}
+
+func (m *{{.TypeName}}) DeleteByPK(q sq.CommonQuery) (int64, error) {
+	return q.Where(sq.WriterToFunc(m.SQLPrimaryKey)).Delete(m)
+}
+{{end}}
+
 {{if .IsJoin}}
 func (m *{{.TypeName}}) SQLSelect(w SQLWriter) error {
 	(*{{.TypeName}})(nil).__sqlSelect(w)
@@ -177,14 +318,18 @@ func (m *{{.TypeName}}) SQLSelect(w SQLWriter) error {
 	panic("sqlgen: expect {{plural .NumJoins "type"}} to join")
 	}
 	w.WriteRawString("FROM ")
-	w.WriteName({{.TableName | go}})
+	{{writeTableName .Schema .TableName}}
 	w.WriteRawString(" AS ")
 	w.WriteRawString(string({{._As}}))
 	{{range $i, $join := .Joins -}}
 		w.WriteByte(' ')
 		w.WriteRawString(string(types[{{$i}}]))
 		w.WriteRawString(" JOIN ")
+		{{if $join.JoinDef.SchemaName -}}
+		{{writeTableName $join.JoinDef.SchemaName $join.JoinDef.TableName}}
+		{{- else -}}
 		w.WriteName({{$join.JoinType | tableForType}})
+		{{- end}}
 		w.WriteRawString(" AS ")
 		w.WriteRawString(string({{$._JoinAs}}[{{$i}}]))
 		w.WriteRawString(" ON ")
@@ -202,6 +347,25 @@ func (m *{{.TypeName}}) SQLSelect(w SQLWriter) error {
 	{{end}}
 	return args
 	}
+
+	func (m *{{.TypeName}}) SQLColumnValue(opts core.Opts, col string) (interface{}, bool) {
+	alias, name := core.SplitColumn(col)
+	switch sq.AS(alias) {
+	case {{._As}}:
+		if m.{{.BaseType | typeName}} == nil {
+			return nil, true
+		}
+		return m.{{.BaseType | typeName}}.SQLColumnValue(opts, name)
+	{{range $i, $join := .Joins -}}
+	case {{$._JoinAs}}[{{$i}}]:
+		if m.{{$join.JoinType | typeName}} == nil {
+			return nil, true
+		}
+		return m.{{$join.JoinType | typeName}}.SQLColumnValue(opts, name)
+	{{end -}}
+	}
+	return nil, false
+	}
 {{end}}
 
 {{if .IsPreload}}
@@ -209,12 +373,28 @@ func (m *{{.TypeName}}) SQLPreload(table string) *core.PreloadDesc {
 	switch table {
 	{{range .Preloads -}}
 	case {{.TableName | go}}:
+		{{if .IsManyToMany -}}
+		return {{$.TypeNames}}{m}.SQLPreload(table)
+		{{- else -}}
 		var items {{.PluralTypeStr}}
+		{{if .IsBelongsTo -}}
+		ids := make([]interface{}, 0, {{len .Fkeys}})
+		{{.AppendRefIDs "m"}}
+		{{end -}}
 		return &core.PreloadDesc{
+			{{if gt (len .Fkeys) 1 -}}
+			Fkeys: {{.Fkeys | go}},
+			{{- else -}}
 			Fkey: {{.Fkey | go}},
-			IDs: []interface{}{m.ID},
+			{{- end}}
+			{{if .IsBelongsTo -}}
+			IDs: ids,
+			{{- else -}}
+			IDs: []interface{}{ {{- range $i, $f := $.PKFields}}{{if $i}}, {{end}}m.{{$f}}{{end -}} },
+			{{- end}}
 			Items: &items,
 		}
+		{{- end}}
 	{{end -}}
 	default:
 		return nil
@@ -224,18 +404,77 @@ func (m *{{.TypeName}}) SQLPreload(table string) *core.PreloadDesc {
 func (m {{.TypeNames}}) SQLPreload(table string) *core.PreloadDesc {
 	switch table {
 	{{range .Preloads -}}
+	{{if .IsManyToMany -}}
+	case {{.TableName | go}}:
+		ids := make([]interface{}, 0, len(m))
+		for _, item := range m {
+			ids = append(ids, item.{{.ParentKey.FieldName}})
+		}
+		type link struct {
+			Fkey {{.ParentKey.GoType}}
+			Ref  {{.RefKeyType}}
+		}
+		var links []link
+		var items {{.PluralTypeStr}}
+		return &core.PreloadDesc{
+			Fkey: {{.Fkey | go}},
+			IDs: ids,
+			Items: &items,
+			Through: {{queryTableName $.Schema .Through | go}},
+			Ref: {{.Ref | go}},
+			RefKey: {{.RefKey | go}},
+			Link: func() []interface{} {
+				links = append(links, link{})
+				l := &links[len(links)-1]
+				return []interface{}{&l.Fkey, &l.Ref}
+			},
+			RefIDs: func() []interface{} {
+				refs := make([]interface{}, len(links))
+				for i, l := range links {
+					refs[i] = l.Ref
+				}
+				return refs
+			},
+			Populate: func() error {
+				mapKey := make(map[{{.ParentKey.GoType}}]*{{$.TypeName}}, len(m))
+				for _, item := range m {
+					mapKey[item.{{.ParentKey.FieldName}}] = item
+				}
+				mapItem := make(map[{{.RefKeyType}}]*{{.TypeStr}}, len(items))
+				for _, item := range items {
+					mapItem[item.{{.RefKeyField}}] = item
+				}
+				for _, l := range links {
+					mitem, item := mapKey[l.Fkey], mapItem[l.Ref]
+					if mitem != nil && item != nil {
+						mitem.{{.FieldName}} = append(mitem.{{.FieldName}}, item)
+					}
+				}
+				return nil
+			},
+		}
+	{{else -}}
 	case {{.TableName | go}}:
-		ids := make([]interface{}, len(m))
-		for i, item := range m {
-			ids[i] = item.ID
+		ids := make([]interface{}, 0, len(m)*{{len .Fkeys}})
+		for _, item := range m {
+			{{if .IsBelongsTo -}}
+			{{.AppendRefIDs "item"}}
+			{{- else -}}
+			ids = append(ids{{range $.PKFields}}, item.{{.}}{{end}})
+			{{- end}}
 		}
 		var items {{.PluralTypeStr}}
 		return &core.PreloadDesc{
+			{{if gt (len .Fkeys) 1 -}}
+			Fkeys: {{.Fkeys | go}},
+			{{- else -}}
 			Fkey: {{.Fkey | go}},
+			{{- end}}
 			IDs: ids,
 			Items: &items,
 		}
 	{{end -}}
+	{{end -}}
 	default:
 		return nil
 	}
@@ -244,36 +483,114 @@ func (m {{.TypeNames}}) SQLPreload(table string) *core.PreloadDesc {
 func (m *{{.TypeName}}) SQLPopulate(items core.IFind) error {
 	switch items := items.(type) {
 	{{range .Preloads -}}
+	{{if not .IsManyToMany -}}
 		case *{{.PluralTypeStr}}:
+		{{if .IsSlice -}}
 		m.{{.FieldName}} = *items
+		{{- else -}}
+		if len(*items) != 0 {
+			m.{{.FieldName}} = (*items)[0]
+		}
+		{{- end}}
 		return nil
 	{{end -}}
+	{{end -}}
 	default:
 		return core.Errorf("can not populate %%T into %%T", items, m)
 	}
 }
 
 func (m {{.TypeNames}}) SQLPopulate(items core.IFind) error {
-	mapID := make(map[int64]*{{.TypeName}})
+	{{if .HasParentKey -}}
+	mapKey := make(map[{{.MapKeyType}}]*{{.TypeName}})
 	for _, item := range m {
-		mapID[item.ID] = item
+		mapKey[{{.MapKey}}] = item
 	}
 
+	{{end -}}
 	switch items := items.(type) {
 	{{range .Preloads -}}
+	{{if not .IsManyToMany -}}
 	case *{{.PluralTypeStr}}:
+		{{if .IsBelongsTo -}}
+		mapItem := make(map[{{.RefKeyType}}]*{{.TypeStr}}, len(*items))
+		for _, item := range *items {
+			mapItem[{{.RefItemKey}}] = item
+		}
+		for _, item := range m {
+			{{.PopulateRef "item"}}
+		}
+		{{- else -}}
 		for _, item := range *items {
-			mitem := mapID[item.{{.Fkey | toTitle}}]
+			mitem := mapKey[{{.ItemKey}}]
 			if mitem == nil {
-				return core.Errorf("can not populate id %%v", item.{{.Fkey | toTitle}})
+				return core.Errorf("can not populate id %%v", {{.ItemKey}})
 			}
+			{{if .IsSlice -}}
 			mitem.{{.FieldName}} = append(mitem.{{.FieldName}}, item)
+			{{- else -}}
+			mitem.{{.FieldName}} = item
+			{{- end}}
 		}
+		{{- end}}
 		return nil
 	{{end -}}
+	{{end -}}
 	default:
 		return core.Errorf("can not populate %%T into %%T", items, m)
 	}
 }
+
+{{range .Preloads -}}
+{{if .IsManyToMany}}
+// Add{{.FieldName}} links {{.PluralTypeStr}} to m by inserting into {{.Through | go}}.
+func (m *{{$.TypeName}}) Add{{.FieldName}}(tx sq.Tx, items ...*{{.TypeStr}}) (int64, error) {
+	if len(items) == 0 {
+		return 0, nil
+	}
+	res, err := tx.SQL(sq.WriterToFunc(func(w SQLWriter) error {
+		w.WriteRawString("INSERT INTO ")
+		{{writeTableName $.Schema .Through}}
+		w.WriteRawString(" (")
+		w.WriteName({{.Fkey | go}})
+		w.WriteByte(',')
+		w.WriteName({{.Ref | go}})
+		w.WriteRawString(") VALUES ")
+		for _, item := range items {
+			w.WriteByte('(')
+			w.WriteMarkers(2)
+			w.WriteArgs([]interface{}{m.{{.ParentKey.FieldName}}, item.{{.RefKeyField}}})
+			w.WriteRawString("),")
+		}
+		w.TrimLast(1)
+		return nil
+	})).Exec()
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
+
+// Remove{{.FieldName}} unlinks {{.PluralTypeStr}} from m by deleting from {{.Through | go}}.
+func (m *{{$.TypeName}}) Remove{{.FieldName}}(tx sq.Tx, items ...*{{.TypeStr}}) (int64, error) {
+	if len(items) == 0 {
+		return 0, nil
+	}
+	refs := make([]interface{}, len(items))
+	for i, item := range items {
+		refs[i] = item.{{.RefKeyField}}
+	}
+	res, err := tx.SQL(sq.WriterToFunc(func(w SQLWriter) error {
+		w.WriteRawString("DELETE FROM ")
+		{{writeTableName $.Schema .Through}}
+		return nil
+	})).In({{.Fkey | go}}, m.{{.ParentKey.FieldName}}).In({{.Ref | go}}, refs).Exec()
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
+{{end -}}
+{{end -}}
 {{end}}
 `
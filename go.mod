@@ -2,10 +2,12 @@ module github.com/ng-vu/sqlgen
 
 require (
 	github.com/davecgh/go-spew v1.1.1 // indirect
+	github.com/go-sql-driver/mysql v1.5.0
 	github.com/go-test/deep v1.0.1 // indirect
 	github.com/gopherjs/gopherjs v0.0.0-20181017120253-0766667cb4d1 // indirect
 	github.com/jtolds/gls v4.2.1+incompatible // indirect
 	github.com/lib/pq v0.0.0-20180523175426-90697d60dd84
+	github.com/mattn/go-sqlite3 v1.14.16
 	github.com/ng-vu/goconveyx v0.0.0-20180602123644-10bc073ba239
 	github.com/pmezard/go-difflib v1.0.0 // indirect
 	github.com/smartystreets/assertions v0.0.0-20180301161246-7678a5452ebe // indirect
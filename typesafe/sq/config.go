@@ -2,9 +2,12 @@ package sq
 
 import (
 	"context"
+	"database/sql"
 	"database/sql/driver"
 	"encoding/json"
+	"errors"
 	"log"
+	"math/rand"
 	"sync"
 	"time"
 
@@ -18,6 +21,9 @@ type Error = core.Error
 var (
 	ErrNoColumn = core.ErrNoColumn
 	ErrNoRows   = core.ErrNoRows
+
+	// ErrStop is returned from the func of Iterate to stop the iteration.
+	ErrStop = errors.New("sqlgen: stop iteration")
 )
 
 // Option ...
@@ -34,23 +40,31 @@ func (fn OptionFunc) SQLOption(db *Database) {
 }
 
 // QuestionMarker ...
+//
+// Deprecated: The marker is chosen by the dialect, see SetDialect.
 var QuestionMarker = OptionFunc(func(db *Database) {
-	db.marker = '?'
+	db.opts.Dialect = withQuoteAndMarker(db.opts.Dialect, 0, '?')
 })
 
 // DollarMarker ...
+//
+// Deprecated: The marker is chosen by the dialect, see SetDialect.
 var DollarMarker = OptionFunc(func(db *Database) {
-	db.marker = '$'
+	db.opts.Dialect = withQuoteAndMarker(db.opts.Dialect, 0, '$')
 })
 
 // DoubleQuoteEscape ...
+//
+// Deprecated: The quote is chosen by the dialect, see SetDialect.
 func DoubleQuoteEscape(db *Database) {
-	db.quote = '"'
+	db.opts.Dialect = withQuoteAndMarker(db.opts.Dialect, '"', 0)
 }
 
 // BacktickEscape ...
+//
+// Deprecated: The quote is chosen by the dialect, see SetDialect.
 func BacktickEscape(db *Database) {
-	db.quote = '`'
+	db.opts.Dialect = withQuoteAndMarker(db.opts.Dialect, '`', 0)
 }
 
 // UseArrayInsteadOfJSON ...
@@ -58,6 +72,17 @@ func UseArrayInsteadOfJSON(db *Database, b bool) {
 	db.opts.UseArrayInsteadOfJSON = b
 }
 
+// MaxParams limits the number of parameters in a statement. Insert splits
+// slices into multiple statements to stay within the limit. The default is the
+// limit of the dialect: 65535 for Postgres and MySQL, 32766 for SQLite. It
+// should be lower for MySQL when wide rows exceed max_allowed_packet.
+type MaxParams int
+
+// SQLOption ...
+func (n MaxParams) SQLOption(db *Database) {
+	db.maxParams = int(n)
+}
+
 // PoolConfig connection pool config
 type PoolConfig struct {
 	MaxLifetime time.Duration // <= 0: connections are reused forever
@@ -65,15 +90,69 @@ type PoolConfig struct {
 	MaxOpen     int           // default 0: unlimited
 }
 
+// SQLOption applies the config to the primary and the replicas.
 func (cfg PoolConfig) SQLOption(db *Database) {
+	db.pool = &cfg
+	cfg.apply(db.db)
+	for _, r := range db.replicas {
+		cfg.apply(r.conn)
+	}
+}
+
+func (cfg PoolConfig) apply(db *sql.DB) {
 	// MaxOpen <= 0 means no limit on the number of open connections
-	db.db.SetMaxOpenConns(cfg.MaxOpen)
+	db.SetMaxOpenConns(cfg.MaxOpen)
 
 	// MaxIdle <= 0 means no idle connections are retained
-	db.db.SetMaxIdleConns(cfg.MaxIdle)
+	db.SetMaxIdleConns(cfg.MaxIdle)
 
 	// MaxLifetime <= 0 means connections are reused forever
-	db.db.SetConnMaxLifetime(cfg.MaxLifetime)
+	db.SetConnMaxLifetime(cfg.MaxLifetime)
+}
+
+// RetryConfig configures the retries of InTx. Each retry waits for a random
+// backoff between half and all of MinBackoff * 2^(n-1), capped by MaxBackoff,
+// where n is the number of failed attempts.
+type RetryConfig struct {
+	MaxAttempts int           // default 3, 1 disables retries
+	MinBackoff  time.Duration // default 10ms
+	MaxBackoff  time.Duration // default 1s
+}
+
+func (cfg RetryConfig) SQLOption(db *Database) {
+	db.retry = cfg
+}
+
+func (cfg RetryConfig) maxAttempts() int {
+	if cfg.MaxAttempts <= 0 {
+		return 3
+	}
+	return cfg.MaxAttempts
+}
+
+func (cfg RetryConfig) backoff(attempt int) time.Duration {
+	minBackoff, maxBackoff := cfg.MinBackoff, cfg.MaxBackoff
+	if minBackoff <= 0 {
+		minBackoff = 10 * time.Millisecond
+	}
+	if maxBackoff <= 0 {
+		maxBackoff = time.Second
+	}
+	d := minBackoff
+	for i := 1; i < attempt && d < maxBackoff; i++ {
+		d *= 2
+	}
+	if d > maxBackoff {
+		d = maxBackoff
+	}
+	return d/2 + time.Duration(rand.Int63n(int64(d/2)+1))
+}
+
+// Retryable is implemented by errors, usually returned by ErrorMapper, to tell
+// InTx whether to retry the transaction. Other errors are classified by the
+// dialect.
+type Retryable interface {
+	Retryable() bool
 }
 
 // Type ...
@@ -178,8 +257,19 @@ type LogEntry struct {
 
 	Flags `json:"flags"`
 
+	// The node which served the query, NodePrimary or a replica
+	Node string `json:"node,omitempty"`
+
+	// Only be set by CopyFrom
+	Rows int64 `json:"rows,omitempty"`
+
 	// Only be set if Type is Commit or Revert
-	TxQueries []*LogEntry `json:"tx_queries"`
+	TxQueries []*LogEntry        `json:"tx_queries"`
+	Isolation sql.IsolationLevel `json:"isolation,omitempty"`
+
+	// Only be set if Type is Commit or Revert of a transaction started by InTx,
+	// starting from 1
+	Attempt int `json:"attempt,omitempty"`
 }
 
 // Logger ...
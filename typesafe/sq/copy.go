@@ -0,0 +1,127 @@
+package sq
+
+import (
+	"context"
+	"database/sql/driver"
+	"time"
+
+	"github.com/lib/pq"
+
+	"github.com/ng-vu/sqlgen/core"
+)
+
+type copyItem interface {
+	core.ITableName
+	SQLArgs(opts core.Opts, create bool) []interface{}
+}
+
+// CopyFrom inserts objs, usually a slice type like Users, with COPY FROM STDIN
+// inside a transaction. It is much faster than INSERT for large batches, but
+// does not call insert hooks. Other databases than Postgres fall back to INSERT
+// statements, which do not call the hooks either.
+func (db *Database) CopyFrom(ctx context.Context, objs core.ITableName) (int64, error) {
+	tx, err := db.BeginContext(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer func() { _ = tx.Rollback() }()
+	n, err := tx.CopyFrom(ctx, objs)
+	if err != nil {
+		return 0, err
+	}
+	return n, tx.Commit()
+}
+
+// CopyFrom inserts objs with COPY FROM STDIN. See Database.CopyFrom.
+func (tx *tx) CopyFrom(ctx context.Context, objs core.ITableName) (_ int64, err error) {
+	if tx.db.opts.Dialect.Name() != Postgres.Name() {
+		return tx.insertWithoutHooks(ctx, objs)
+	}
+	cols, ok := objs.(core.IColumns)
+	if !ok {
+		return 0, core.Errorf("sqlgen: %T does not have columns to copy", objs)
+	}
+	items := itemsOf(objs)
+	if len(items) == 0 {
+		return 0, nil
+	}
+
+	var query string
+	if schemaName := schemaNameOf(objs); schemaName != "" {
+		query = pq.CopyInSchema(schemaName, objs.SQLTableName(), cols.SQLColumns()...)
+	} else {
+		query = pq.CopyIn(objs.SQLTableName(), cols.SQLColumns()...)
+	}
+	entry := &LogEntry{
+		Ctx:   ctx,
+		Query: query,
+		Time:  time.Now(),
+		Flags: Flags(TypeExec) | FlagTx,
+		Node:  NodePrimary,
+	}
+	tx.addQuery(entry)
+	defer func() {
+		entry.Error = err
+		err = tx.db.log(entry)
+	}()
+
+	stmt, err := tx.tx.PrepareContext(ctx, query)
+	if err != nil {
+		return 0, err
+	}
+	defer func() { _ = stmt.Close() }()
+	for _, item := range items {
+		item, ok := item.(copyItem)
+		if !ok {
+			return 0, core.Errorf("sqlgen: %T can not be copied", item)
+		}
+		args := item.SQLArgs(tx.db.opts, true)
+		for i, arg := range args {
+			if args[i], err = copyValue(arg); err != nil {
+				return 0, err
+			}
+		}
+		if _, err = stmt.ExecContext(ctx, args...); err != nil {
+			return 0, err
+		}
+		entry.Rows++
+	}
+
+	// Flush the buffered rows
+	if _, err = stmt.ExecContext(ctx); err != nil {
+		return 0, err
+	}
+	return entry.Rows, nil
+}
+
+// insertWithoutHooks inserts objs with INSERT statements, split as with Insert
+// but without calling the hooks.
+func (tx *tx) insertWithoutHooks(ctx context.Context, objs core.ITableName) (int64, error) {
+	if _, ok := objs.(core.IInsert); !ok {
+		return 0, core.Errorf("sqlgen: %T can not be inserted", objs)
+	}
+	q := tx.NewQuery().WithContext(ctx).(*queryImpl)
+	var count int64
+	for _, chunk := range q.chunksOf(objs) {
+		n, err := q.insert(chunk.(core.IInsert))
+		if err != nil {
+			return 0, err
+		}
+		count += n
+	}
+	return count, nil
+}
+
+// copyValue converts arg to a value which can be encoded by COPY. JSON and
+// arrays must be sent as text, because []byte is encoded as bytea.
+func copyValue(arg interface{}) (interface{}, error) {
+	switch arg := arg.(type) {
+	case core.JSON, core.Array:
+		v, err := arg.(driver.Valuer).Value()
+		if b, ok := v.([]byte); ok {
+			return string(b), err
+		}
+		return v, err
+	}
+	return arg, nil
+}
@@ -0,0 +1,260 @@
+package sq
+
+import (
+	"database/sql/driver"
+	"encoding/base64"
+	"encoding/json"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/ng-vu/sqlgen/core"
+)
+
+// cursorPart is the keyset pagination set by After or Before. An empty value
+// requests the first page, or the last page with Before.
+type cursorPart struct {
+	value  string
+	before bool
+}
+
+type orderCol struct {
+	name string
+	desc bool
+}
+
+// parseOrderBys returns the columns of the ORDER BY expressions. Keyset
+// pagination compares the columns as a row value, therefore all columns must
+// have the same direction.
+func parseOrderBys(orderBys []string) ([]orderCol, error) {
+	var cols []orderCol
+	for _, s := range orderBys {
+		for _, part := range strings.Split(s, ",") {
+			fields := strings.Fields(part)
+			if len(fields) == 0 || len(fields) > 2 {
+				return nil, core.Errorf("sqlgen: unsupported ORDER BY for cursor: %v", part)
+			}
+			col := orderCol{name: fields[0]}
+			if len(fields) == 2 {
+				switch strings.ToUpper(fields[1]) {
+				case "ASC":
+				case "DESC":
+					col.desc = true
+				default:
+					return nil, core.Errorf("sqlgen: unsupported ORDER BY for cursor: %v", part)
+				}
+			}
+			cols = append(cols, col)
+		}
+	}
+	if len(cols) == 0 {
+		return nil, core.Errorf("sqlgen: cursor requires ORDER BY")
+	}
+	for _, col := range cols {
+		if col.desc != cols[0].desc {
+			return nil, core.Errorf("sqlgen: cursor requires the same direction for all ORDER BY columns")
+		}
+	}
+	return cols, nil
+}
+
+// cursorCond is the condition (a,b) > (?,?) of keyset pagination.
+type cursorCond struct {
+	cols   []orderCol
+	less   bool
+	values []interface{}
+}
+
+func (p cursorCond) WriteSQLTo(w core.SQLWriter) error {
+	w.WriteByte('(')
+	for _, col := range p.cols {
+		w.WriteQueryName(col.name)
+		w.WriteByte(',')
+	}
+	w.TrimLast(1)
+	if p.less {
+		w.WriteRawString(") < (")
+	} else {
+		w.WriteRawString(") > (")
+	}
+	w.WriteMarkers(len(p.cols))
+	w.WriteByte(')')
+	w.WriteArgs(p.values)
+	return nil
+}
+
+// cursorCond returns the condition of the cursor and the ORDER BY columns, in
+// reversed direction for Before.
+func (q *queryImpl) cursorCond() (*cursorCond, []orderCol, error) {
+	cols, err := parseOrderBys(q.orderBys)
+	if err != nil {
+		return nil, nil, err
+	}
+	if q.cursor.before {
+		reversed := make([]orderCol, len(cols))
+		for i, col := range cols {
+			reversed[i] = orderCol{name: col.name, desc: !col.desc}
+		}
+		cols = reversed
+	}
+	if q.cursor.value == "" {
+		return nil, cols, nil
+	}
+	values, err := decodeCursor(q.cursor.value)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(values) != len(cols) {
+		return nil, nil, core.Errorf("sqlgen: invalid cursor")
+	}
+	return &cursorCond{cols: cols, less: cols[0].desc, values: values}, cols, nil
+}
+
+// Cursor returns the cursor of obj for requesting the following rows with
+// After, or the preceding rows with Before. It is usually called with the last
+// row of a page for After, or the first row for Before. The cursor holds the
+// values of the ORDER BY columns of obj and is opaque to clients.
+func (q *queryImpl) Cursor(obj core.IColumnValue) (string, error) {
+	cols, err := parseOrderBys(q.orderBys)
+	if err != nil {
+		return "", err
+	}
+	values := make([]cursorValue, len(cols))
+	for i, col := range cols {
+		v, ok := obj.SQLColumnValue(q.opts, col.name)
+		if !ok {
+			return "", core.Errorf("sqlgen: unknown cursor column: %v", col.name)
+		}
+		if values[i], err = cursorValueOf(v); err != nil {
+			return "", err
+		}
+	}
+	return encodeCursor(values)
+}
+
+// Types of cursor values
+const (
+	cursorNull   = "null"
+	cursorString = "string"
+	cursorInt    = "int"
+	cursorFloat  = "float"
+	cursorBool   = "bool"
+	cursorTime   = "time"
+	cursorBytes  = "bytes"
+)
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// cursorValue is a value of a cursor, tagged with its type so that it is
+// decoded to the same type. Otherwise times would be decoded as strings.
+type cursorValue struct {
+	Type  string          `json:"t"`
+	Value json.RawMessage `json:"v,omitempty"`
+}
+
+// cursorValueOf returns the cursor value of a column value. Valuers which
+// write zero values as NULL, like String, keep their zero values, because NULL
+// does not match any row.
+func cursorValueOf(v interface{}) (cursorValue, error) {
+	if valuer, ok := v.(driver.Valuer); ok {
+		dv, err := valuer.Value()
+		if err != nil {
+			return cursorValue{}, err
+		}
+		if dv != nil {
+			v = dv
+		}
+	}
+	rv := reflect.ValueOf(v)
+	for rv.Kind() == reflect.Ptr {
+		if rv.IsNil() {
+			return cursorValue{Type: cursorNull}, nil
+		}
+		rv = rv.Elem()
+	}
+	var typ string
+	switch {
+	case !rv.IsValid():
+		return cursorValue{Type: cursorNull}, nil
+	case rv.Kind() == reflect.Struct && rv.Type().ConvertibleTo(timeType):
+		typ, v = cursorTime, rv.Convert(timeType).Interface().(time.Time).Format(time.RFC3339Nano)
+	case rv.Kind() == reflect.String:
+		typ, v = cursorString, rv.String()
+	case rv.Kind() >= reflect.Int && rv.Kind() <= reflect.Int64:
+		typ, v = cursorInt, rv.Int()
+	case rv.Kind() >= reflect.Uint && rv.Kind() <= reflect.Uint64:
+		typ, v = cursorInt, int64(rv.Uint())
+	case rv.Kind() == reflect.Float32 || rv.Kind() == reflect.Float64:
+		typ, v = cursorFloat, rv.Float()
+	case rv.Kind() == reflect.Bool:
+		typ, v = cursorBool, rv.Bool()
+	case rv.Kind() == reflect.Slice && rv.Type().Elem().Kind() == reflect.Uint8:
+		typ, v = cursorBytes, rv.Bytes()
+	default:
+		return cursorValue{}, core.Errorf("sqlgen: unsupported cursor value %T", v)
+	}
+	data, err := json.Marshal(v)
+	return cursorValue{Type: typ, Value: data}, err
+}
+
+// value returns the argument of the cursor value.
+func (c cursorValue) value() (interface{}, error) {
+	var err error
+	switch c.Type {
+	case cursorNull:
+		return nil, nil
+	case cursorString:
+		var v string
+		err = json.Unmarshal(c.Value, &v)
+		return v, err
+	case cursorInt:
+		var v int64
+		err = json.Unmarshal(c.Value, &v)
+		return v, err
+	case cursorFloat:
+		var v float64
+		err = json.Unmarshal(c.Value, &v)
+		return v, err
+	case cursorBool:
+		var v bool
+		err = json.Unmarshal(c.Value, &v)
+		return v, err
+	case cursorTime:
+		var s string
+		if err = json.Unmarshal(c.Value, &s); err != nil {
+			return nil, err
+		}
+		return time.Parse(time.RFC3339Nano, s)
+	case cursorBytes:
+		var v []byte
+		err = json.Unmarshal(c.Value, &v)
+		return v, err
+	}
+	return nil, core.Errorf("sqlgen: invalid cursor")
+}
+
+func encodeCursor(values []cursorValue) (string, error) {
+	data, err := json.Marshal(values)
+	if err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(data), nil
+}
+
+func decodeCursor(s string) ([]interface{}, error) {
+	data, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, core.Errorf("sqlgen: invalid cursor")
+	}
+	var cvs []cursorValue
+	if err = json.Unmarshal(data, &cvs); err != nil {
+		return nil, core.Errorf("sqlgen: invalid cursor")
+	}
+	values := make([]interface{}, len(cvs))
+	for i, cv := range cvs {
+		if values[i], err = cv.value(); err != nil {
+			return nil, core.Errorf("sqlgen: invalid cursor")
+		}
+	}
+	return values, nil
+}
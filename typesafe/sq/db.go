@@ -3,6 +3,7 @@ package sq
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"time"
 
 	"github.com/ng-vu/sqlgen/core"
@@ -13,10 +14,17 @@ type Database struct {
 	db *sql.DB
 
 	opts   core.Opts
-	quote  byte
-	marker byte
 	logger Logger
 	mapper ErrorMapper
+
+	maxParams int
+	retry     RetryConfig
+
+	replicas        []*replica
+	replicaConnStrs []string
+	balancer        Balancer
+	next            uint32
+	pool            *PoolConfig
 }
 
 // Connect ...
@@ -26,19 +34,35 @@ func Connect(driver, connStr string, opts ...Option) (*Database, error) {
 		return nil, err
 	}
 	db := &Database{db: _db, logger: func(_ *LogEntry) {}}
-
-	switch driver {
-	case "postgres", "cloudsqlpostgres":
-		DollarMarker(db)
-		DoubleQuoteEscape(db)
+	db.opts.Dialect = dialectOf(driver)
+	db.maxParams = -1 // the limit of the dialect unless MaxParams is given
+	if db.opts.Dialect == Postgres {
 		UseArrayInsteadOfJSON(db, true)
-	default:
-		QuestionMarker(db)
-		BacktickEscape(db)
 	}
 	for _, opt := range opts {
 		opt.SQLOption(db)
 	}
+	if db.maxParams < 0 {
+		db.maxParams = db.opts.Dialect.MaxParams()
+	}
+	// The databases given by ReplicaDBs are closed by the caller.
+	given := len(db.replicas)
+	for _, s := range db.replicaConnStrs {
+		r, err := sql.Open(driver, s)
+		if err != nil {
+			for _, r := range db.replicas[given:] {
+				_ = r.conn.Close()
+			}
+			_ = db.db.Close()
+			return nil, err
+		}
+		db.addReplica(r)
+	}
+	if db.pool != nil {
+		for _, r := range db.replicas {
+			db.pool.apply(r.conn)
+		}
+	}
 	return db, nil
 }
 
@@ -67,6 +91,7 @@ func (db *Database) ExecContext(ctx context.Context, query string, args ...inter
 		Args:  args,
 		Time:  time.Now(),
 		Flags: Flags(TypeExec),
+		Node:  NodePrimary,
 	}
 	defer func() {
 		entry.Error = err
@@ -91,18 +116,23 @@ func (db *Database) MustExec(query string, args ...interface{}) sql.Result {
 
 // QueryContext ...
 func (db *Database) QueryContext(ctx context.Context, query string, args ...interface{}) (_ *sql.Rows, err error) {
+	return db.queryContext(ctx, NodePrimary, db.db, query, args)
+}
+
+func (db *Database) queryContext(ctx context.Context, node string, conn *sql.DB, query string, args []interface{}) (_ *sql.Rows, err error) {
 	entry := &LogEntry{
 		Ctx:   ctx,
 		Query: query,
 		Args:  args,
 		Time:  time.Now(),
 		Flags: Flags(TypeQuery),
+		Node:  node,
 	}
 	defer func() {
 		entry.Error = err
 		err = db.log(entry)
 	}()
-	return db.db.QueryContext(ctx, query, args...)
+	return conn.QueryContext(ctx, query, args...)
 }
 
 // Query ...
@@ -112,15 +142,20 @@ func (db *Database) Query(query string, args ...interface{}) (_ *sql.Rows, err e
 
 // QueryRowContext ...
 func (db *Database) QueryRowContext(ctx context.Context, query string, args ...interface{}) Row {
+	return db.queryRowContext(ctx, NodePrimary, db.db, query, args)
+}
+
+func (db *Database) queryRowContext(ctx context.Context, node string, conn *sql.DB, query string, args []interface{}) Row {
 	entry := &LogEntry{
 		Ctx:   ctx,
 		Query: query,
 		Args:  args,
 		Time:  time.Now(),
 		Flags: Flags(TypeQueryRow),
+		Node:  node,
 	}
 	return Row{
-		Row: db.db.QueryRowContext(ctx, query, args...),
+		Row: conn.QueryRowContext(ctx, query, args...),
 		Log: func(err error) error {
 			entry.Error = err
 			return db.log(entry)
@@ -147,18 +182,80 @@ func (db *Database) log(entry *LogEntry) (err error) {
 
 // Begin ...
 func (db *Database) Begin() (Tx, error) {
-	t, err := db.db.Begin()
+	return db.BeginTx(context.Background(), TxOptions{})
+}
+
+// BeginContext ...
+func (db *Database) BeginContext(ctx context.Context) (Tx, error) {
+	return db.BeginTx(ctx, TxOptions{})
+}
+
+// BeginTx starts a transaction with the isolation level and read-only mode of
+// opts. The context is used for all statements of the transaction, and the
+// driver rolls back the transaction when it is done.
+func (db *Database) BeginTx(ctx context.Context, opts TxOptions) (Tx, error) {
+	t, err := db.beginTx(ctx, opts)
 	if err != nil {
 		return nil, err
 	}
-	return &tx{tx: t, db: db, t0: time.Now(), ctx: context.Background()}, nil
+	return t, nil
 }
 
-// BeginContext ...
-func (db *Database) BeginContext(ctx context.Context) (Tx, error) {
-	t, err := db.db.Begin()
+func (db *Database) beginTx(ctx context.Context, opts TxOptions) (*tx, error) {
+	t, err := db.db.BeginTx(ctx, &opts)
 	if err != nil {
 		return nil, err
 	}
-	return &tx{tx: t, db: db, t0: time.Now(), ctx: ctx}, nil
+	return &tx{tx: t, db: db, t0: time.Now(), ctx: ctx, opts: opts}, nil
+}
+
+// InTx calls fn in a transaction, which is committed when fn returns nil and
+// rolled back when fn returns an error or panics. The transaction is retried
+// with backoff when it fails with a retryable error, like a serialization
+// failure or a deadlock, see RetryConfig and Retryable. Therefore fn may be
+// called more than once and should not have side effects outside of the
+// transaction.
+func (db *Database) InTx(ctx context.Context, opts TxOptions, fn func(Tx) error) error {
+	for attempt := 1; ; attempt++ {
+		err := db.inTx(ctx, opts, attempt, fn)
+		if err == nil || attempt >= db.retry.maxAttempts() || !db.isRetryable(err) {
+			return err
+		}
+		timer := time.NewTimer(db.retry.backoff(attempt))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return err
+		case <-timer.C:
+		}
+	}
+}
+
+func (db *Database) inTx(ctx context.Context, opts TxOptions, attempt int, fn func(Tx) error) (err error) {
+	tx, err := db.beginTx(ctx, opts)
+	if err != nil {
+		return err
+	}
+	tx.attempt = attempt
+	defer func() {
+		if p := recover(); p != nil {
+			_ = tx.Rollback()
+			panic(p)
+		}
+		if err != nil {
+			_ = tx.Rollback()
+		}
+	}()
+	if err = fn(tx); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func (db *Database) isRetryable(err error) bool {
+	var r Retryable
+	if errors.As(err, &r) {
+		return r.Retryable()
+	}
+	return db.opts.Dialect.Retryable(err)
 }
@@ -0,0 +1,339 @@
+package sq
+
+import (
+	"database/sql/driver"
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/go-sql-driver/mysql"
+	"github.com/lib/pq"
+
+	"github.com/ng-vu/sqlgen/core"
+)
+
+// Dialect ...
+type Dialect = core.Dialect
+
+// Dialects
+var (
+	Postgres Dialect = postgresDialect{}
+	MySQL    Dialect = mysqlDialect{}
+	SQLite   Dialect = sqliteDialect{}
+)
+
+// SetDialect overrides the dialect chosen from the driver name by Connect.
+func SetDialect(dialect Dialect) Option {
+	return OptionFunc(func(db *Database) {
+		db.opts.Dialect = dialect
+	})
+}
+
+// dialectOf returns the dialect for the given driver name.
+func dialectOf(driver string) Dialect {
+	switch driver {
+	case "postgres", "cloudsqlpostgres":
+		return Postgres
+	case "sqlite3", "sqlite":
+		return SQLite
+	default:
+		return MySQL
+	}
+}
+
+type postgresDialect struct{}
+
+func (postgresDialect) Name() string { return "postgres" }
+
+func (postgresDialect) Quote() byte { return '"' }
+
+func (postgresDialect) AppendMarker(b []byte, n int64) []byte {
+	b = append(b, '$')
+	return strconv.AppendInt(b, n, 10)
+}
+
+func (postgresDialect) TupleUpdate() bool { return true }
+
+func (postgresDialect) Returning() bool { return true }
+
+func (postgresDialect) UpdateFrom() bool { return true }
+
+func (postgresDialect) WriteOnConflict(w core.SQLWriter, conflictCols []string, updateCols []string) {
+	writeOnConflict(w, conflictCols, updateCols)
+}
+
+func (postgresDialect) WriteLimit(w core.SQLWriter, limit string, offset string) {
+	writeLimit(w, limit, offset)
+}
+
+func (postgresDialect) ArrayValue(v interface{}) (driver.Value, error) {
+	return core.PostgresArrayValue(v)
+}
+
+func (postgresDialect) ScanArray(v interface{}, src interface{}) error {
+	return core.ScanPostgresArray(v, src)
+}
+
+// Retryable reports serialization_failure (40001) and deadlock_detected
+// (40P01).
+func (postgresDialect) Retryable(err error) bool {
+	var e *pq.Error
+	return errors.As(err, &e) && (e.Code == "40001" || e.Code == "40P01")
+}
+
+func (postgresDialect) MaxParams() int { return 65535 }
+
+type mysqlDialect struct{}
+
+func (mysqlDialect) Name() string { return "mysql" }
+
+func (mysqlDialect) Quote() byte { return '`' }
+
+func (mysqlDialect) AppendMarker(b []byte, n int64) []byte {
+	return append(b, '?')
+}
+
+func (mysqlDialect) TupleUpdate() bool { return false }
+
+func (mysqlDialect) Returning() bool { return false }
+
+func (mysqlDialect) UpdateFrom() bool { return false }
+
+// WriteOnConflict writes ON DUPLICATE KEY UPDATE. MySQL always checks all
+// unique keys, therefore conflictCols is only used to emulate DO NOTHING with a
+// no-op update.
+func (mysqlDialect) WriteOnConflict(w core.SQLWriter, conflictCols []string, updateCols []string) {
+	w.WriteRawString(" ON DUPLICATE KEY UPDATE ")
+	if len(updateCols) == 0 && len(conflictCols) != 0 {
+		w.WriteName(conflictCols[0])
+		w.WriteRawString(" = ")
+		w.WriteName(conflictCols[0])
+		return
+	}
+	for i, col := range updateCols {
+		if i != 0 {
+			w.WriteByte(',')
+		}
+		w.WriteName(col)
+		w.WriteRawString(" = VALUES(")
+		w.WriteName(col)
+		w.WriteByte(')')
+	}
+}
+
+// WriteLimit writes the limit of MySQL, which does not accept OFFSET without
+// LIMIT.
+func (mysqlDialect) WriteLimit(w core.SQLWriter, limit string, offset string) {
+	if limit == "" && offset != "" {
+		limit = "18446744073709551615"
+	}
+	writeLimit(w, limit, offset)
+}
+
+// ArrayValue encodes v as JSON, because MySQL has no arrays.
+func (mysqlDialect) ArrayValue(v interface{}) (driver.Value, error) {
+	return core.JSON{V: v}.Value()
+}
+
+func (mysqlDialect) ScanArray(v interface{}, src interface{}) error {
+	return core.JSON{V: v}.Scan(src)
+}
+
+// Retryable reports ER_LOCK_DEADLOCK (1213).
+func (mysqlDialect) Retryable(err error) bool {
+	var e *mysql.MySQLError
+	return errors.As(err, &e) && e.Number == 1213
+}
+
+func (mysqlDialect) MaxParams() int { return 65535 }
+
+type sqliteDialect struct{}
+
+func (sqliteDialect) Name() string { return "sqlite" }
+
+func (sqliteDialect) Quote() byte { return '"' }
+
+func (sqliteDialect) AppendMarker(b []byte, n int64) []byte {
+	return append(b, '?')
+}
+
+func (sqliteDialect) TupleUpdate() bool { return true }
+
+// Returning requires SQLite 3.35 or later.
+func (sqliteDialect) Returning() bool { return true }
+
+// UpdateFrom requires SQLite 3.33 or later.
+func (sqliteDialect) UpdateFrom() bool { return true }
+
+func (sqliteDialect) WriteOnConflict(w core.SQLWriter, conflictCols []string, updateCols []string) {
+	writeOnConflict(w, conflictCols, updateCols)
+}
+
+// WriteLimit writes the limit of SQLite, which does not accept OFFSET without
+// LIMIT.
+func (sqliteDialect) WriteLimit(w core.SQLWriter, limit string, offset string) {
+	if limit == "" && offset != "" {
+		limit = "-1"
+	}
+	writeLimit(w, limit, offset)
+}
+
+// ArrayValue encodes v as JSON, because SQLite has no arrays.
+func (sqliteDialect) ArrayValue(v interface{}) (driver.Value, error) {
+	return core.JSON{V: v}.Value()
+}
+
+// convertArg stores JSON and arrays as TEXT instead of BLOB, with times in UTC
+// like Postgres arrays of timestamptz.
+func (sqliteDialect) convertArg(arg interface{}) interface{} {
+	switch arg := arg.(type) {
+	case core.JSON:
+		return jsonText{arg}
+	case core.Array:
+		arg.V = utcTimes(arg.V)
+		return jsonText{arg}
+	}
+	return arg
+}
+
+func (sqliteDialect) ScanArray(v interface{}, src interface{}) error {
+	return core.JSON{V: v}.Scan(src)
+}
+
+// Retryable reports nothing, because SQLite serializes writes and waits for
+// locks with the busy timeout of the driver.
+func (sqliteDialect) Retryable(err error) bool { return false }
+
+// MaxParams returns SQLITE_MAX_VARIABLE_NUMBER, which defaults to 32766 since
+// SQLite 3.32.
+func (sqliteDialect) MaxParams() int { return 32766 }
+
+// argConverter is implemented by dialects which encode some arguments
+// differently from the drivers.
+type argConverter interface {
+	convertArg(arg interface{}) interface{}
+}
+
+// jsonText encodes JSON as string instead of []byte.
+type jsonText struct {
+	driver.Valuer
+}
+
+func (v jsonText) Value() (driver.Value, error) {
+	value, err := v.Valuer.Value()
+	if b, ok := value.([]byte); ok {
+		return string(b), err
+	}
+	return value, err
+}
+
+// utcTimes returns a copy of a slice of times in UTC. Other values are returned
+// as is.
+func utcTimes(v interface{}) interface{} {
+	switch v := v.(type) {
+	case []time.Time:
+		if v == nil {
+			return v
+		}
+		res := make([]time.Time, len(v))
+		for i, t := range v {
+			res[i] = t.UTC()
+		}
+		return res
+	case []*time.Time:
+		if v == nil {
+			return v
+		}
+		res := make([]*time.Time, len(v))
+		for i, t := range v {
+			if t != nil {
+				t := t.UTC()
+				res[i] = &t
+			}
+		}
+		return res
+	}
+	return v
+}
+
+// legacyDialect overrides the quote and the marker of a dialect, for the
+// deprecated options like QuestionMarker and NewWriter.
+type legacyDialect struct {
+	Dialect
+	quote  byte
+	marker byte
+}
+
+func (d legacyDialect) Quote() byte { return d.quote }
+
+func (d legacyDialect) AppendMarker(b []byte, n int64) []byte {
+	if d.marker == '$' {
+		return Postgres.AppendMarker(b, n)
+	}
+	return append(b, '?')
+}
+
+// withQuoteAndMarker returns dialect with the given quote and marker, which
+// are kept when zero. Without dialect, it is Postgres for the marker '$',
+// otherwise MySQL.
+func withQuoteAndMarker(dialect Dialect, quote byte, marker byte) Dialect {
+	if dialect == nil {
+		dialect = MySQL
+		if marker == '$' {
+			dialect = Postgres
+		}
+	}
+	d, ok := dialect.(legacyDialect)
+	if !ok {
+		d = legacyDialect{Dialect: dialect, quote: dialect.Quote(), marker: dialect.AppendMarker(nil, 1)[0]}
+	}
+	if quote != 0 {
+		d.quote = quote
+	}
+	if marker != 0 {
+		d.marker = marker
+	}
+	return d
+}
+
+// writeOnConflict writes ON CONFLICT ... DO UPDATE, which is shared by Postgres
+// and SQLite.
+func writeOnConflict(w core.SQLWriter, conflictCols []string, updateCols []string) {
+	w.WriteRawString(" ON CONFLICT")
+	if len(conflictCols) != 0 {
+		w.WriteRawString(" (")
+		for i, col := range conflictCols {
+			if i != 0 {
+				w.WriteByte(',')
+			}
+			w.WriteName(col)
+		}
+		w.WriteByte(')')
+	}
+	if len(updateCols) == 0 {
+		w.WriteRawString(" DO NOTHING")
+		return
+	}
+	w.WriteRawString(" DO UPDATE SET ")
+	for i, col := range updateCols {
+		if i != 0 {
+			w.WriteByte(',')
+		}
+		w.WriteName(col)
+		w.WriteRawString(" = EXCLUDED.")
+		w.WriteName(col)
+	}
+}
+
+func writeLimit(w core.SQLWriter, limit string, offset string) {
+	if limit != "" {
+		w.WriteRawString("LIMIT ")
+		w.WriteRawString(limit)
+		w.WriteByte(' ')
+	}
+	if offset != "" {
+		w.WriteRawString("OFFSET ")
+		w.WriteRawString(offset)
+		w.WriteByte(' ')
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"errors"
 	"fmt"
 	"reflect"
+	"strings"
 
 	"github.com/ng-vu/sqlgen/core"
 )
@@ -326,6 +327,34 @@ func (ps Once) WriteSQLTo(w core.SQLWriter) error {
 type preloadPart struct {
 	table string
 	preds []interface{}
+	funcs []func(CommonQuery)
+
+	// Preloads of the next level, e.g. "order_line" in "order.order_line"
+	children preloadParts
+}
+
+type preloadParts []*preloadPart
+
+// add returns the part of the given path (e.g. "order.order_line.product"),
+// creating the missing parts along the way.
+func (ps *preloadParts) add(path string) *preloadPart {
+	var part *preloadPart
+	parts := ps
+	for _, table := range strings.Split(path, ".") {
+		part = nil
+		for _, p := range *parts {
+			if p.table == table {
+				part = p
+				break
+			}
+		}
+		if part == nil {
+			part = &preloadPart{table: table}
+			*parts = append(*parts, part)
+		}
+		parts = &part.children
+	}
+	return part
 }
 
 /*
@@ -554,3 +583,21 @@ func Filter(prefix, pred string, args ...interface{}) WriterTo {
 		return nil
 	})
 }
+
+func containsString(ss []string, s string) bool {
+	for _, item := range ss {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
+
+// reverseSlice reverses the slice pointed to by objs.
+func reverseSlice(objs interface{}) {
+	v := reflect.ValueOf(objs).Elem()
+	swap := reflect.Swapper(v.Interface())
+	for i, j := 0, v.Len()-1; i < j; i, j = i+1, j-1 {
+		swap(i, j)
+	}
+}
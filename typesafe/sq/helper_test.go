@@ -31,7 +31,7 @@ func byIDPtr(ID *int64) *ColumnFilterPtr {
 }
 
 func compose(writerTos ...WriterTo) (string, []interface{}, error) {
-	w := NewWriter(core.Opts{}, '"', '$', 1024)
+	w := NewDialectWriter(core.Opts{Dialect: Postgres}, 1024)
 	for _, wt := range writerTos {
 		err := wt.WriteSQLTo(w)
 		if err != nil {
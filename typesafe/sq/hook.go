@@ -0,0 +1,165 @@
+package sq
+
+import (
+	"context"
+	"reflect"
+)
+
+// Hooks are called by the query with its context and the active transaction.
+// Insert, Update and Delete run inside an implicit transaction when the object
+// has hooks, and an error returned from a hook aborts the statement and rolls
+// back that transaction. AfterFind is called after Get and Find, with a nil Tx
+// when the query does not run in a transaction.
+//
+// When the object is a slice or a pointer to a slice which does not implement
+// the hook itself, the hook is called on each item.
+//
+// BeforeInsertInterface and BeforeUpdateInterface are the hooks without
+// context and transaction, which are still called for objects implementing
+// them.
+
+type BeforeInsertInterface interface {
+	BeforeInsert() error
+}
+
+type BeforeInsertTxInterface interface {
+	BeforeInsert(ctx context.Context, tx Tx) error
+}
+
+type AfterInsertInterface interface {
+	AfterInsert(ctx context.Context, tx Tx) error
+}
+
+type BeforeUpdateInterface interface {
+	BeforeUpdate() error
+}
+
+type BeforeUpdateTxInterface interface {
+	BeforeUpdate(ctx context.Context, tx Tx) error
+}
+
+type AfterUpdateInterface interface {
+	AfterUpdate(ctx context.Context, tx Tx) error
+}
+
+type BeforeDeleteInterface interface {
+	BeforeDelete(ctx context.Context, tx Tx) error
+}
+
+type AfterDeleteInterface interface {
+	AfterDelete(ctx context.Context, tx Tx) error
+}
+
+type AfterFindInterface interface {
+	AfterFind(ctx context.Context, tx Tx) error
+}
+
+type hookFn func(ctx context.Context, tx Tx) error
+
+// hookFunc returns the hook of obj, or nil if obj does not implement it.
+type hookFunc func(obj interface{}) hookFn
+
+func beforeInsert(obj interface{}) hookFn {
+	switch h := obj.(type) {
+	case BeforeInsertTxInterface:
+		return h.BeforeInsert
+	case BeforeInsertInterface:
+		return func(context.Context, Tx) error { return h.BeforeInsert() }
+	}
+	return nil
+}
+
+func afterInsert(obj interface{}) hookFn {
+	if h, ok := obj.(AfterInsertInterface); ok {
+		return h.AfterInsert
+	}
+	return nil
+}
+
+func beforeUpdate(obj interface{}) hookFn {
+	switch h := obj.(type) {
+	case BeforeUpdateTxInterface:
+		return h.BeforeUpdate
+	case BeforeUpdateInterface:
+		return func(context.Context, Tx) error { return h.BeforeUpdate() }
+	}
+	return nil
+}
+
+func afterUpdate(obj interface{}) hookFn {
+	if h, ok := obj.(AfterUpdateInterface); ok {
+		return h.AfterUpdate
+	}
+	return nil
+}
+
+func beforeDelete(obj interface{}) hookFn {
+	if h, ok := obj.(BeforeDeleteInterface); ok {
+		return h.BeforeDelete
+	}
+	return nil
+}
+
+func afterDelete(obj interface{}) hookFn {
+	if h, ok := obj.(AfterDeleteInterface); ok {
+		return h.AfterDelete
+	}
+	return nil
+}
+
+func afterFind(obj interface{}) hookFn {
+	if h, ok := obj.(AfterFindInterface); ok {
+		return h.AfterFind
+	}
+	return nil
+}
+
+// hooksOf returns the hook of obj, or the hooks of its items when obj is a
+// slice or a pointer to a slice.
+func hooksOf(obj interface{}, hook hookFunc) []hookFn {
+	if fn := hook(obj); fn != nil {
+		return []hookFn{fn}
+	}
+	var fns []hookFn
+	for _, item := range itemsOf(obj) {
+		if fn := hook(item); fn != nil {
+			fns = append(fns, fn)
+		}
+	}
+	return fns
+}
+
+// itemsOf returns the items of obj when it is a slice or a pointer to a slice,
+// otherwise obj itself.
+func itemsOf(obj interface{}) []interface{} {
+	v := reflect.ValueOf(obj)
+	if v.Kind() == reflect.Ptr {
+		v = v.Elem()
+	}
+	if v.Kind() != reflect.Slice {
+		return []interface{}{obj}
+	}
+	items := make([]interface{}, v.Len())
+	for i := range items {
+		items[i] = v.Index(i).Interface()
+	}
+	return items
+}
+
+func hasHooks(obj interface{}, hooks ...hookFunc) bool {
+	for _, hook := range hooks {
+		if len(hooksOf(obj, hook)) != 0 {
+			return true
+		}
+	}
+	return false
+}
+
+func (q *queryImpl) runHooks(tx Tx, fns []hookFn) error {
+	for _, fn := range fns {
+		if err := fn(q.ctx, tx); err != nil {
+			return err
+		}
+	}
+	return nil
+}
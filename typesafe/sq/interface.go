@@ -3,6 +3,9 @@ package sq
 import (
 	"context"
 	"database/sql"
+	"log"
+	"runtime/debug"
+	"strconv"
 	"time"
 
 	"github.com/ng-vu/sqlgen/core"
@@ -30,14 +33,6 @@ type dbInterface interface {
 	log(*LogEntry) error
 }
 
-type BeforeInsertInterface interface {
-	BeforeInsert() error
-}
-
-type BeforeUpdateInterface interface {
-	BeforeUpdate() error
-}
-
 // Get ...
 func (db *Database) Get(obj core.IGet, preds ...interface{}) (bool, error) {
 	return db.NewQuery().Get(obj, preds...)
@@ -48,11 +43,21 @@ func (db *Database) Find(objs core.IFind, preds ...interface{}) error {
 	return db.NewQuery().Find(objs, preds...)
 }
 
+// Iterate ...
+func (db *Database) Iterate(ctx context.Context, obj core.IIterate, fn func() error) error {
+	return db.NewQuery().Iterate(ctx, obj, fn)
+}
+
 // Insert ...
 func (db *Database) Insert(objs ...core.IInsert) (int64, error) {
 	return db.NewQuery().Insert(objs...)
 }
 
+// Upsert ...
+func (db *Database) Upsert(objs ...core.IUpsert) (int64, error) {
+	return db.NewQuery().Upsert(objs...)
+}
+
 // Update ...
 func (db *Database) Update(objs ...core.IUpdate) (int64, error) {
 	return db.NewQuery().Update(objs...)
@@ -123,6 +128,16 @@ func (db *Database) Offset(offset uint64) Query {
 	return db.NewQuery().Offset(offset)
 }
 
+// After requests the rows following the given cursor, see Query.After.
+func (db *Database) After(cursor string) Query {
+	return db.NewQuery().After(cursor)
+}
+
+// Before requests the rows preceding the given cursor, see Query.Before.
+func (db *Database) Before(cursor string) Query {
+	return db.NewQuery().Before(cursor)
+}
+
 // Suffix adds an expression to the end of the query
 func (db *Database) Suffix(sql string, args ...interface{}) Query {
 	return db.NewQuery().Suffix(sql, args...)
@@ -133,6 +148,42 @@ func (db *Database) UpdateAll() Query {
 	return db.NewQuery().UpdateAll()
 }
 
+// UpdateValues ...
+func (db *Database) UpdateValues() Query {
+	return db.NewQuery().UpdateValues()
+}
+
+// OnConflict ...
+func (db *Database) OnConflict(cols ...string) Query {
+	return db.NewQuery().OnConflict(cols...)
+}
+
+// DoNothing ...
+func (db *Database) DoNothing() Query {
+	return db.NewQuery().DoNothing()
+}
+
+// DoUpdate ...
+func (db *Database) DoUpdate(cols ...string) Query {
+	return db.NewQuery().DoUpdate(cols...)
+}
+
+// SkipCreated ...
+func (db *Database) SkipCreated() Query {
+	return db.NewQuery().SkipCreated()
+}
+
+// Returning ...
+func (db *Database) Returning() Query {
+	return db.NewQuery().Returning()
+}
+
+// UsePrimary reads from the primary instead of a replica, see
+// Query.UsePrimary.
+func (db *Database) UsePrimary() Query {
+	return db.NewQuery().UsePrimary()
+}
+
 // In ...
 func (db *Database) In(column string, args ...interface{}) Query {
 	return db.NewQuery().In(column, args...)
@@ -163,19 +214,40 @@ func (db *Database) Apply(funcs ...func(CommonQuery)) Query {
 
 // Tx ...
 type Tx interface {
+	Begin() (Tx, error)
+	BeginContext(ctx context.Context) (Tx, error)
 	Commit() error
 	Rollback() error
+	OnCommit(fn func())
+	OnRollback(fn func())
+	CopyFrom(ctx context.Context, objs core.ITableName) (int64, error)
 
 	DBInterface
 	CommonQuery
 }
 
+// TxOptions holds the isolation level and read-only mode of a transaction.
+type TxOptions = sql.TxOptions
+
 type tx struct {
-	tx  *sql.Tx
-	db  *Database
-	t0  time.Time
-	qs  []*LogEntry
-	ctx context.Context
+	tx   *sql.Tx
+	db   *Database
+	t0   time.Time
+	qs   []*LogEntry
+	ctx  context.Context
+	opts TxOptions
+
+	// attempt of InTx, or 0
+	attempt int
+
+	// Only be set for savepoints created by Begin
+	parent     *tx
+	savepoint  string
+	done       bool
+	savepoints int
+
+	onCommit   []func()
+	onRollback []func()
 }
 
 func (tx *tx) log(e *LogEntry) error {
@@ -183,19 +255,119 @@ func (tx *tx) log(e *LogEntry) error {
 	return tx.db.log(e)
 }
 
+// root returns the outermost transaction.
+func (tx *tx) root() *tx {
+	for tx.parent != nil {
+		tx = tx.parent
+	}
+	return tx
+}
+
+// addQuery records the query in the outermost transaction, which logs the
+// queries of all its savepoints on commit or rollback.
+func (tx *tx) addQuery(e *LogEntry) {
+	root := tx.root()
+	root.qs = append(root.qs, e)
+}
+
+// Begin ...
+func (tx *tx) Begin() (Tx, error) {
+	return tx.BeginContext(tx.ctx)
+}
+
+// BeginContext creates a savepoint inside the transaction. Commit releases the
+// savepoint and Rollback rolls back to it, while the outer transaction goes on.
+func (tx *tx) BeginContext(ctx context.Context) (Tx, error) {
+	sp := newSavepoint(tx, ctx)
+	if _, err := sp.ExecContext(ctx, "SAVEPOINT "+sp.savepoint); err != nil {
+		return nil, err
+	}
+	return sp, nil
+}
+
+func newSavepoint(parent *tx, ctx context.Context) *tx {
+	root := parent.root()
+	root.savepoints++
+	return &tx{
+		tx:        parent.tx,
+		db:        parent.db,
+		t0:        time.Now(),
+		ctx:       ctx,
+		opts:      parent.opts,
+		parent:    parent,
+		savepoint: "sqlgen_sp" + strconv.Itoa(root.savepoints),
+	}
+}
+
+// endSavepoint releases or rolls back to the savepoint once.
+func (tx *tx) endSavepoint(stmt string) error {
+	if tx.done {
+		return sql.ErrTxDone
+	}
+	tx.done = true
+	_, err := tx.ExecContext(tx.ctx, stmt+tx.savepoint)
+	return err
+}
+
+// OnCommit registers fn to be called after the transaction is committed.
+// Callbacks of a savepoint are passed to its parent when the savepoint is
+// released, therefore they are called after the outermost commit.
+func (tx *tx) OnCommit(fn func()) {
+	tx.onCommit = append(tx.onCommit, fn)
+}
+
+// OnRollback registers fn to be called after the transaction is rolled back,
+// or fails to commit. Callbacks of a savepoint are called when it is rolled
+// back, or passed to its parent when it is released.
+func (tx *tx) OnRollback(fn func()) {
+	tx.onRollback = append(tx.onRollback, fn)
+}
+
+// runCallbacks calls fns in order. The transaction has already ended,
+// therefore a panic is only logged and does not stop the following callbacks.
+func runCallbacks(fns []func()) {
+	for _, fn := range fns {
+		func() {
+			defer func() {
+				if p := recover(); p != nil {
+					log.Printf("sqlgen: panic in transaction callback: %v\n%s", p, debug.Stack())
+				}
+			}()
+			fn()
+		}()
+	}
+}
+
 // Commit ...
 func (tx *tx) Commit() (err error) {
+	if tx.parent != nil {
+		err = tx.endSavepoint("RELEASE SAVEPOINT ")
+		if err == nil {
+			tx.parent.onCommit = append(tx.parent.onCommit, tx.onCommit...)
+			tx.parent.onRollback = append(tx.parent.onRollback, tx.onRollback...)
+		}
+		return err
+	}
 	defer func() {
 		// Only log once per tx
 		if err == sql.ErrTxDone {
 			return
 		}
+		callbacks := tx.onCommit
+		if err != nil {
+			callbacks = tx.onRollback
+		}
+		defer runCallbacks(callbacks)
+
 		entry := &LogEntry{
 			Ctx:       tx.ctx,
 			Error:     err,
 			Time:      tx.t0,
 			Flags:     Flags(TypeCommit) | FlagTx,
+			Node:      NodePrimary,
 			TxQueries: tx.qs,
+			Isolation: tx.opts.Isolation,
+			Attempt:   tx.attempt,
 		}
 		err = tx.db.log(entry)
 	}()
@@ -204,17 +376,29 @@ func (tx *tx) Commit() (err error) {
 
 // Rollback ...
 func (tx *tx) Rollback() (err error) {
+	if tx.parent != nil {
+		err = tx.endSavepoint("ROLLBACK TO SAVEPOINT ")
+		if err != sql.ErrTxDone {
+			runCallbacks(tx.onRollback)
+		}
+		return err
+	}
 	defer func() {
 		// Only log once per tx
 		if err == sql.ErrTxDone {
 			return
 		}
+		defer runCallbacks(tx.onRollback)
+
 		entry := &LogEntry{
 			Ctx:       tx.ctx,
 			Error:     err,
 			Time:      tx.t0,
 			Flags:     Flags(TypeRollback) | FlagTx,
+			Node:      NodePrimary,
 			TxQueries: tx.qs,
+			Isolation: tx.opts.Isolation,
+			Attempt:   tx.attempt,
 		}
 		err = tx.db.log(entry)
 	}()
@@ -229,13 +413,14 @@ func (tx *tx) ExecContext(ctx context.Context, query string, args ...interface{}
 		Args:  args,
 		Time:  time.Now(),
 		Flags: Flags(TypeExec) | FlagTx,
+		Node:  NodePrimary,
 	}
-	tx.qs = append(tx.qs, entry)
+	tx.addQuery(entry)
 	defer func() {
 		entry.Error = err
 		err = tx.db.log(entry)
 	}()
-	return tx.tx.Exec(query, args...)
+	return tx.tx.ExecContext(ctx, query, args...)
 }
 
 // Exec ...
@@ -250,13 +435,14 @@ func (tx *tx) QueryContext(ctx context.Context, query string, args ...interface{
 		Args:  args,
 		Time:  time.Now(),
 		Flags: Flags(TypeQuery) | FlagTx,
+		Node:  NodePrimary,
 	}
-	tx.qs = append(tx.qs, entry)
+	tx.addQuery(entry)
 	defer func() {
 		entry.Error = err
 		err = tx.db.log(entry)
 	}()
-	return tx.tx.Query(query, args...)
+	return tx.tx.QueryContext(ctx, query, args...)
 }
 
 func (tx *tx) Query(query string, args ...interface{}) (_ *sql.Rows, err error) {
@@ -270,10 +456,11 @@ func (tx *tx) QueryRowContext(ctx context.Context, query string, args ...interfa
 		Args:  args,
 		Time:  time.Now(),
 		Flags: Flags(TypeQueryRow) | FlagTx,
+		Node:  NodePrimary,
 	}
-	tx.qs = append(tx.qs, entry)
+	tx.addQuery(entry)
 	return Row{
-		Row: tx.tx.QueryRow(query, args...),
+		Row: tx.tx.QueryRowContext(ctx, query, args...),
 		Log: func(err error) error {
 			entry.Error = err
 			return tx.db.log(entry)
@@ -295,11 +482,21 @@ func (tx *tx) Find(objs core.IFind, preds ...interface{}) error {
 	return tx.NewQuery().Find(objs, preds...)
 }
 
+// Iterate ...
+func (tx *tx) Iterate(ctx context.Context, obj core.IIterate, fn func() error) error {
+	return tx.NewQuery().Iterate(ctx, obj, fn)
+}
+
 // Insert ...
 func (tx *tx) Insert(objs ...core.IInsert) (int64, error) {
 	return tx.NewQuery().Insert(objs...)
 }
 
+// Upsert ...
+func (tx *tx) Upsert(objs ...core.IUpsert) (int64, error) {
+	return tx.NewQuery().Upsert(objs...)
+}
+
 // Update ...
 func (tx *tx) Update(objs ...core.IUpdate) (int64, error) {
 	return tx.NewQuery().Update(objs...)
@@ -370,6 +567,16 @@ func (tx *tx) Offset(offset uint64) Query {
 	return tx.NewQuery().Offset(offset)
 }
 
+// After requests the rows following the given cursor, see Query.After.
+func (tx *tx) After(cursor string) Query {
+	return tx.NewQuery().After(cursor)
+}
+
+// Before requests the rows preceding the given cursor, see Query.Before.
+func (tx *tx) Before(cursor string) Query {
+	return tx.NewQuery().Before(cursor)
+}
+
 // Suffix adds an expression to the end of the query
 func (tx *tx) Suffix(sql string, args ...interface{}) Query {
 	return tx.NewQuery().Suffix(sql, args...)
@@ -380,6 +587,41 @@ func (tx *tx) UpdateAll() Query {
 	return tx.NewQuery().UpdateAll()
 }
 
+// UpdateValues ...
+func (tx *tx) UpdateValues() Query {
+	return tx.NewQuery().UpdateValues()
+}
+
+// OnConflict ...
+func (tx *tx) OnConflict(cols ...string) Query {
+	return tx.NewQuery().OnConflict(cols...)
+}
+
+// DoNothing ...
+func (tx *tx) DoNothing() Query {
+	return tx.NewQuery().DoNothing()
+}
+
+// DoUpdate ...
+func (tx *tx) DoUpdate(cols ...string) Query {
+	return tx.NewQuery().DoUpdate(cols...)
+}
+
+// SkipCreated ...
+func (tx *tx) SkipCreated() Query {
+	return tx.NewQuery().SkipCreated()
+}
+
+// Returning ...
+func (tx *tx) Returning() Query {
+	return tx.NewQuery().Returning()
+}
+
+// UsePrimary ...
+func (tx *tx) UsePrimary() Query {
+	return tx.NewQuery().UsePrimary()
+}
+
 // In ...
 func (tx *tx) In(column string, args ...interface{}) Query {
 	return tx.NewQuery().In(column, args...)
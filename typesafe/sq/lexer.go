@@ -0,0 +1,178 @@
+package sq
+
+import (
+	"strings"
+
+	"github.com/ng-vu/sqlgen/core"
+)
+
+// appendAndReplace appends query with the quotes and markers of the dialect.
+// Each '?' is a marker, and "$." is replaced by the schema. String literals,
+// comments and dollar-quoted strings are copied as is, and quoted identifiers
+// only have their quotes replaced. The Postgres JSON operators ?| and ?& are
+// not markers.
+func appendAndReplace(b []byte, c *int64, dialect core.Dialect, query string, schema string) []byte {
+	quote := dialect.Quote()
+	backslash := quote == '`' // MySQL escapes with backslash in string literals
+	idx := 0
+	for i := 0; i < len(query); {
+		ch := query[i]
+		switch {
+		case ch == '\'':
+			i = skipString(query, i, backslash || isEscapeString(query, i))
+
+		case ch == '"':
+			end := skipQuoted(query, i, '"')
+			if quote != '"' && query[end-1] == '"' && end-i >= 2 {
+				b = append(b, query[idx:i]...)
+				b = append(b, quote)
+				b = append(b, query[i+1:end-1]...)
+				b = append(b, quote)
+				idx = end
+			}
+			i = end
+
+		case ch == '`':
+			i = skipQuoted(query, i, '`')
+
+		case ch == '-' && peek(query, i+1) == '-':
+			i = skipLineComment(query, i)
+
+		case ch == '/' && peek(query, i+1) == '*':
+			i = skipBlockComment(query, i)
+
+		case ch == '?':
+			if isJSONOperator(query, i) {
+				i += 2
+				continue
+			}
+			*c++
+			b = append(b, query[idx:i]...)
+			b = dialect.AppendMarker(b, *c)
+			i++
+			idx = i
+
+		case ch == '$' && peek(query, i+1) == '.':
+			b = append(b, query[idx:i]...)
+			if schema != "" {
+				b = append(b, schema...)
+				b = append(b, '.')
+			}
+			i += 2
+			idx = i
+
+		case ch == '$':
+			i = skipDollarQuoted(query, i)
+
+		default:
+			i++
+		}
+	}
+	if idx < len(query) {
+		b = append(b, query[idx:]...)
+	}
+	return b
+}
+
+func peek(s string, i int) byte {
+	if i < len(s) {
+		return s[i]
+	}
+	return 0
+}
+
+func isIdentChar(c byte) bool {
+	return c == '_' ||
+		c >= 'a' && c <= 'z' ||
+		c >= 'A' && c <= 'Z' ||
+		c >= '0' && c <= '9'
+}
+
+// isEscapeString reports whether the string literal at i is a Postgres escape
+// string like E'\n'.
+func isEscapeString(s string, i int) bool {
+	if i == 0 || s[i-1] != 'E' && s[i-1] != 'e' {
+		return false
+	}
+	return i == 1 || !isIdentChar(s[i-2])
+}
+
+// isJSONOperator reports whether the '?' at i starts the operator ?| or ?&. A
+// doubled ?|| or ?&& is a marker followed by the operator || or &&.
+func isJSONOperator(s string, i int) bool {
+	next := peek(s, i+1)
+	if next != '|' && next != '&' {
+		return false
+	}
+	return peek(s, i+2) != next
+}
+
+// skipString returns the end of the string literal at i. A quote is escaped by
+// doubling it, or with backslash when backslash is set.
+func skipString(s string, i int, backslash bool) int {
+	for j := i + 1; j < len(s); j++ {
+		switch s[j] {
+		case '\\':
+			if backslash {
+				j++
+			}
+		case '\'':
+			if peek(s, j+1) != '\'' {
+				return j + 1
+			}
+			j++
+		}
+	}
+	return len(s)
+}
+
+// skipQuoted returns the end of the identifier quoted by q at i. The quote is
+// escaped by doubling it.
+func skipQuoted(s string, i int, q byte) int {
+	for j := i + 1; j < len(s); j++ {
+		if s[j] == q {
+			if peek(s, j+1) != q {
+				return j + 1
+			}
+			j++
+		}
+	}
+	return len(s)
+}
+
+func skipLineComment(s string, i int) int {
+	if j := strings.IndexByte(s[i:], '\n'); j >= 0 {
+		return i + j + 1
+	}
+	return len(s)
+}
+
+func skipBlockComment(s string, i int) int {
+	if j := strings.Index(s[i+2:], "*/"); j >= 0 {
+		return i + 2 + j + 2
+	}
+	return len(s)
+}
+
+// skipDollarQuoted returns the end of the dollar-quoted string like $$...$$ or
+// $tag$...$tag$ at i. Otherwise, e.g. for the marker $1, it returns i+1.
+func skipDollarQuoted(s string, i int) int {
+	if i > 0 && isIdentChar(s[i-1]) {
+		return i + 1
+	}
+	j := i + 1
+	for j < len(s) && isIdentChar(s[j]) {
+		j++
+	}
+	if j == len(s) || s[j] != '$' {
+		return i + 1
+	}
+	if c := s[i+1]; c >= '0' && c <= '9' {
+		return i + 1
+	}
+	tag := s[i : j+1]
+	if k := strings.Index(s[j+1:], tag); k >= 0 {
+		return j + 1 + k + len(tag)
+	}
+	return len(s)
+}
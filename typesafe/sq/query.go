@@ -3,8 +3,8 @@ package sq
 import (
 	"context"
 	"database/sql"
-	"errors"
 	"fmt"
+	"reflect"
 	"strconv"
 
 	"github.com/ng-vu/sqlgen/core"
@@ -15,11 +15,13 @@ type queryImpl struct {
 	db  dbInterface
 	ctx context.Context
 
-	opts      core.Opts
-	quote     byte
-	marker    byte
-	updateAll bool
-	withTable bool
+	opts       core.Opts
+	maxParams  int
+	updateAll  bool
+	updateVal  bool
+	withTable  bool
+	returning  bool
+	usePrimary bool
 
 	table  string
 	limit  string
@@ -34,7 +36,16 @@ type queryImpl struct {
 	groupBys   []string
 	suffixes   Parts
 
-	preloads []preloadPart
+	preloads preloadParts
+	upsert   upsertOpts
+	cursor   *cursorPart
+}
+
+type upsertOpts struct {
+	conflictCols []string
+	updateCols   []string
+	doNothing    bool
+	skipCreated  bool
 }
 
 var _ Query = &queryImpl{}
@@ -45,9 +56,8 @@ func (db *Database) NewQuery() Query {
 		db:  db,
 		ctx: context.Background(),
 
-		opts:   db.opts,
-		quote:  db.quote,
-		marker: db.marker,
+		opts:      db.opts,
+		maxParams: db.maxParams,
 	}
 }
 
@@ -57,19 +67,17 @@ func (tx *tx) NewQuery() Query {
 		db:  tx,
 		ctx: tx.ctx,
 
-		opts:   tx.db.opts,
-		quote:  tx.db.quote,
-		marker: tx.db.marker,
+		opts:      tx.db.opts,
+		maxParams: tx.db.maxParams,
 	}
 }
 
 func (q *queryImpl) NewQuery() Query {
 	return &queryImpl{
-		db:     q.db,
-		ctx:    q.ctx,
-		opts:   q.opts,
-		quote:  q.quote,
-		marker: q.marker,
+		db:        q.db,
+		ctx:       q.ctx,
+		opts:      q.opts,
+		maxParams: q.maxParams,
 	}
 }
 
@@ -96,10 +104,14 @@ func (q *queryImpl) cloneWithPreds(preds []interface{}) *queryImpl {
 		db:         q.db,
 		ctx:        q.ctx,
 		opts:       q.opts,
-		quote:      q.quote,
-		marker:     q.marker,
+		maxParams:  q.maxParams,
 		updateAll:  q.updateAll,
+		updateVal:  q.updateVal,
+		upsert:     q.upsert,
 		withTable:  q.withTable,
+		returning:  q.returning,
+		usePrimary: q.usePrimary,
+		cursor:     q.cursor,
 		table:      q.table,
 		limit:      q.limit,
 		offset:     q.offset,
@@ -124,10 +136,23 @@ func (q *queryImpl) cloneWithPreds(preds []interface{}) *queryImpl {
 	return nq
 }
 
+// withPrimaryKey uses the primary key of obj as the WHERE condition when no
+// WHERE is given.
+func (q *queryImpl) withPrimaryKey(obj interface{}) *queryImpl {
+	if len(q.whereParts) != 0 {
+		return q
+	}
+	pk, ok := obj.(core.IPrimaryKey)
+	if !ok {
+		return q
+	}
+	return q.cloneWithPreds([]interface{}{WriterToFunc(pk.SQLPrimaryKey)})
+}
+
 type builderFunc func(core.SQLWriter) error
 
 func (q *queryImpl) build(typ string, def interface{}, fn builderFunc) (_ string, _ []interface{}, err error) {
-	w := NewWriter(q.opts, q.quote, q.marker, 512)
+	w := NewDialectWriter(q.opts, 512)
 	defer func() {
 		if err != nil {
 			entry := &LogEntry{
@@ -190,9 +215,20 @@ func (q *queryImpl) build(typ string, def interface{}, fn builderFunc) (_ string
 		}
 		w.WriteByte(' ')
 	}
-	if len(q.whereParts) != 0 {
+	whereParts, orderCols := q.whereParts, []orderCol(nil)
+	if q.cursor != nil {
+		var cond *cursorCond
+		cond, orderCols, err = q.cursorCond()
+		if err != nil {
+			return
+		}
+		if cond != nil {
+			whereParts = append(whereParts[:len(whereParts):len(whereParts)], *cond)
+		}
+	}
+	if len(whereParts) != 0 {
 		w.WriteRawString("WHERE (")
-		err = q.whereParts.WriteSQLTo(w, ") AND (")
+		err = whereParts.WriteSQLTo(w, ") AND (")
 		if err != nil {
 			return
 		}
@@ -208,7 +244,19 @@ func (q *queryImpl) build(typ string, def interface{}, fn builderFunc) (_ string
 		}
 		w.WriteByte(' ')
 	}
-	if len(q.orderBys) != 0 {
+	if len(orderCols) != 0 {
+		w.WriteRawString("ORDER BY ")
+		for i, col := range orderCols {
+			if i != 0 {
+				w.WriteByte(',')
+			}
+			w.WriteQueryName(col.name)
+			if col.desc {
+				w.WriteRawString(" DESC")
+			}
+		}
+		w.WriteByte(' ')
+	} else if len(q.orderBys) != 0 {
 		w.WriteRawString("ORDER BY ")
 		for i, s := range q.orderBys {
 			if i != 0 {
@@ -218,15 +266,8 @@ func (q *queryImpl) build(typ string, def interface{}, fn builderFunc) (_ string
 		}
 		w.WriteByte(' ')
 	}
-	if q.limit != "" {
-		w.WriteRawString("LIMIT ")
-		w.WriteRawString(q.limit)
-		w.WriteByte(' ')
-	}
-	if q.offset != "" {
-		w.WriteRawString("OFFSET ")
-		w.WriteRawString(q.offset)
-		w.WriteByte(' ')
+	if q.limit != "" || q.offset != "" {
+		q.opts.Dialect.WriteLimit(w, q.limit, q.offset)
 	}
 	if len(q.suffixes) != 0 {
 		err = q.suffixes.WriteSQLTo(w, " ")
@@ -255,23 +296,64 @@ func (q *queryImpl) assertTable(obj core.ITableName) {
 	}
 }
 
+// schemaNameOf returns the schema name of obj, or an empty string if obj is not
+// declared with a schema.
+func schemaNameOf(obj core.ITableName) string {
+	if s, ok := obj.(core.ISchemaName); ok {
+		return s.SQLSchemaName()
+	}
+	return ""
+}
+
+// BuildPreload builds the query for preloading table of obj. For many-to-many
+// preloads, it is the query of the link table.
 func (q *queryImpl) BuildPreload(table string, obj interface{}, preds ...interface{}) (string, []interface{}, error) {
+	_, query, args, err := q.buildPreload(&preloadPart{table: table, preds: preds}, obj)
+	return query, args, err
+}
+
+func (q *queryImpl) buildPreload(part *preloadPart, obj interface{}) (*core.PreloadDesc, string, []interface{}, error) {
 	preloader, ok := obj.(core.IPreload)
 	if !ok {
-		return "", nil, core.Errorf("sqlgen: %T does not support preload", obj)
+		return nil, "", nil, core.Errorf("sqlgen: %T does not support preload", obj)
 	}
-	desc := preloader.SQLPreload(table)
+	desc := preloader.SQLPreload(part.table)
 	if desc == nil {
-		return "", nil, core.Errorf("sqlgen: %T does not support preload table %v", obj, table)
+		return nil, "", nil, core.Errorf("sqlgen: %T does not support preload table %v", obj, part.table)
 	}
 
 	fkey, ids, items := desc.Fkey, desc.IDs, desc.Items
-	if ids == nil || fkey == "" || items == nil {
-		return "", nil, core.Errorf("sqlgen: invalid preload description")
+	if ids == nil || fkey == "" && len(desc.Fkeys) == 0 || items == nil {
+		return nil, "", nil, core.Errorf("sqlgen: invalid preload description")
 	}
 
-	nq := q.NewQuery().In(fkey, ids).Where(preds...)
-	return nq.BuildFind(items)
+	if desc.Through != "" {
+		if desc.Ref == "" || desc.RefKey == "" ||
+			desc.Link == nil || desc.RefIDs == nil || desc.Populate == nil {
+			return nil, "", nil, core.Errorf("sqlgen: invalid preload description")
+		}
+		query, args, err := q.NewQuery().
+			Select(fkey, desc.Ref).From(desc.Through).
+			In(fkey, ids).Build()
+		return desc, query, args, err
+	}
+
+	var pred WriterTo
+	if len(desc.Fkeys) != 0 {
+		pred = Ins(desc.Fkeys, ids)
+	} else {
+		pred = In(fkey, ids)
+	}
+	query, args, err := q.buildPreloadItems(part, desc, pred)
+	return desc, query, args, err
+}
+
+func (q *queryImpl) buildPreloadItems(part *preloadPart, desc *core.PreloadDesc, pred WriterTo) (string, []interface{}, error) {
+	nq := q.NewQuery().Where(pred).Where(part.preds...)
+	for _, fn := range part.funcs {
+		fn(nq)
+	}
+	return nq.BuildFind(desc.Items)
 }
 
 // Build ...
@@ -279,27 +361,79 @@ func (q *queryImpl) Build(preds ...interface{}) (string, []interface{}, error) {
 	return q.withPreds(preds).build("", nil, nil)
 }
 
-func (q *queryImpl) doPreloads(obj interface{}) error {
-	if len(q.preloads) == 0 {
+// doPreloads loads the preloaded tables of obj level by level. Each level runs
+// a single query for all items of the previous level.
+func (q *queryImpl) doPreloads(obj interface{}, parts preloadParts) error {
+	if len(parts) == 0 {
 		return nil
 	}
-	exprs := make([]ExprString, len(q.preloads))
-	for i, preload := range q.preloads {
-		query, args, err := q.BuildPreload(preload.table, obj, preload.preds...)
+	preloader, ok := obj.(core.IPreload)
+	if !ok {
+		return core.Errorf("sqlgen: %T does not support preload", obj)
+	}
+	for _, part := range parts {
+		desc, query, args, err := q.buildPreload(part, obj)
+		if err != nil {
+			return err
+		}
+		if ids, ok := desc.IDs.([]interface{}); ok && len(ids) == 0 {
+			continue
+		}
+		if desc.Through != "" {
+			err = q.doPreloadThrough(part, desc, query, args)
+		} else {
+			err = q.doPreload(query, args, desc.Items)
+			if err == nil {
+				err = preloader.SQLPopulate(desc.Items)
+			}
+		}
 		if err != nil {
 			return err
 		}
-		exprs[i] = ExprString{query, args}
+		if err = q.doPreloads(desc.Items, part.children); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// doPreloadThrough loads the link table of a many-to-many preload, then the
+// items referenced by the links.
+func (q *queryImpl) doPreloadThrough(part *preloadPart, desc *core.PreloadDesc, query string, args []interface{}) error {
+	rows, err := q.reader().QueryContext(q.ctx, query, args...)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = rows.Close() }()
+	for rows.Next() {
+		if err = rows.Scan(desc.Link()...); err != nil {
+			return err
+		}
 	}
+	if err = rows.Err(); err != nil {
+		return err
+	}
+	_ = rows.Close()
 
-	for _, expr := range exprs {
-		query, args := expr.SQL, expr.Args
-		_, err := q.db.ExecContext(q.ctx, query, args)
+	if refs := desc.RefIDs(); len(refs) != 0 {
+		query, args, err = q.buildPreloadItems(part, desc, In(desc.RefKey, refs))
 		if err != nil {
 			return err
 		}
+		if err = q.doPreload(query, args, desc.Items); err != nil {
+			return err
+		}
 	}
-	return nil
+	return desc.Populate()
+}
+
+func (q *queryImpl) doPreload(query string, args []interface{}, items core.IFind) error {
+	rows, err := q.reader().QueryContext(q.ctx, query, args...)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = rows.Close() }()
+	return items.SQLScan(q.opts, rows)
 }
 
 // BuildGet ...
@@ -317,26 +451,61 @@ func (q *queryImpl) BuildFind(objs core.IFind, preds ...interface{}) (string, []
 // BuildInsert ...
 func (q *queryImpl) BuildInsert(obj core.IInsert) (string, []interface{}, error) {
 	q.assertTable(obj)
-	return q.build("INSERT", nil, obj.SQLInsert)
+	return q.withReturning(obj).build("INSERT", nil, obj.SQLInsert)
+}
+
+// BuildUpsert ...
+func (q *queryImpl) BuildUpsert(obj core.IUpsert) (string, []interface{}, error) {
+	q.assertTable(obj)
+	var updateCols []string
+	if !q.upsert.doNothing {
+		cols := obj.SQLUpsertColumns(q.upsert.skipCreated)
+		if len(q.upsert.updateCols) != 0 {
+			cols = q.upsert.updateCols
+			if q.upsert.skipCreated {
+				cols = excludeCreatedColumns(obj, cols)
+			}
+		}
+		for _, col := range cols {
+			if !containsString(q.upsert.conflictCols, col) {
+				updateCols = append(updateCols, col)
+			}
+		}
+	}
+	return q.withReturning(obj).build("INSERT", nil, func(w core.SQLWriter) error {
+		return obj.SQLUpsert(w, q.upsert.conflictCols, updateCols)
+	})
+}
+
+func excludeCreatedColumns(obj core.IUpsert, cols []string) []string {
+	all, noCreated := obj.SQLUpsertColumns(false), obj.SQLUpsertColumns(true)
+	res := make([]string, 0, len(cols))
+	for _, col := range cols {
+		if containsString(all, col) && !containsString(noCreated, col) {
+			continue
+		}
+		res = append(res, col)
+	}
+	return res
 }
 
 // BuildUpdate ...
 func (q *queryImpl) BuildUpdate(obj core.IUpdate) (string, []interface{}, error) {
 	q.assertTable(obj)
 	fn := obj.SQLUpdate
-	if q.updateAll {
+	if q.updateAll || q.updateVal {
 		fn = obj.SQLUpdateAll
 	}
-	return q.build("UPDATE", nil, fn)
+	return q.withPrimaryKey(obj).withReturning(obj).build("UPDATE", nil, fn)
 }
 
 // BuildDelete ...
 func (q *queryImpl) BuildDelete(obj core.ITableName) (string, []interface{}, error) {
 	q.assertTable(obj)
-	tableName := obj.SQLTableName()
-	return q.build("DELETE", nil, func(w core.SQLWriter) error {
+	schemaName, tableName := schemaNameOf(obj), obj.SQLTableName()
+	return q.withPrimaryKey(obj).build("DELETE", nil, func(w core.SQLWriter) error {
 		w.WriteRawString("DELETE FROM ")
-		w.WriteName(tableName)
+		w.WritePrefixedName(schemaName, tableName)
 		return nil
 	})
 }
@@ -344,8 +513,17 @@ func (q *queryImpl) BuildDelete(obj core.ITableName) (string, []interface{}, err
 // BuildCount ...
 func (q *queryImpl) BuildCount(obj core.ITableName, preds ...interface{}) (string, []interface{}, error) {
 	q.assertTable(obj)
-	q.Select(`COUNT(*)`).Table(obj.SQLTableName())
-	return q.withPreds(preds).build("SELECT", obj, nil)
+	nq := q.cloneWithPreds(preds)
+	schemaName, tableName := schemaNameOf(obj), obj.SQLTableName()
+	if schemaName == "" {
+		nq.Select(`COUNT(*)`).Table(tableName)
+		return nq.build("SELECT", obj, nil)
+	}
+	return nq.build("SELECT", nil, func(w core.SQLWriter) error {
+		w.WriteRawString("SELECT COUNT(*) FROM ")
+		w.WritePrefixedName(schemaName, tableName)
+		return nil
+	})
 }
 
 // Exec ...
@@ -363,7 +541,7 @@ func (q *queryImpl) Query() (*sql.Rows, error) {
 	if err != nil {
 		return nil, err
 	}
-	return q.db.QueryContext(q.ctx, query, args...)
+	return q.readerFor(query).QueryContext(q.ctx, query, args...)
 }
 
 // QueryRow ...
@@ -372,7 +550,7 @@ func (q *queryImpl) QueryRow() (Row, error) {
 	if err != nil {
 		return Row{}, err
 	}
-	return q.db.QueryRowContext(q.ctx, query, args...), nil
+	return q.readerFor(query).QueryRowContext(q.ctx, query, args...), nil
 }
 
 // Scan ...
@@ -381,7 +559,7 @@ func (q *queryImpl) Scan(dest ...interface{}) error {
 	if err != nil {
 		return err
 	}
-	return q.db.QueryRowContext(q.ctx, query, args...).Scan(dest...)
+	return q.readerFor(query).QueryRowContext(q.ctx, query, args...).Scan(dest...)
 }
 
 // Get ...
@@ -391,7 +569,7 @@ func (q *queryImpl) Get(obj core.IGet, preds ...interface{}) (bool, error) {
 	if err != nil {
 		return false, err
 	}
-	row := q.db.QueryRowContext(q.ctx, query, args...)
+	row := q.reader().QueryRowContext(q.ctx, query, args...)
 	sqlErr := obj.SQLScan(q.opts, row.Row)
 
 	// The above SQLScan() is called with *sql.Row, therefore logging and
@@ -401,7 +579,10 @@ func (q *queryImpl) Get(obj core.IGet, preds ...interface{}) (bool, error) {
 		return false, nil
 	}
 	if err == nil && len(q.preloads) > 0 {
-		err = q.doPreloads(obj)
+		err = q.doPreloads(obj, q.preloads)
+	}
+	if err == nil {
+		err = q.runHooks(q.currentTx(), hooksOf(obj, afterFind))
 	}
 	return err == nil, err
 }
@@ -412,79 +593,487 @@ func (q *queryImpl) Find(objs core.IFind, preds ...interface{}) error {
 	if err != nil {
 		return err
 	}
-	rows, err := q.db.QueryContext(q.ctx, query, args...)
+	rows, err := q.reader().QueryContext(q.ctx, query, args...)
 	if err != nil {
 		return err
 	}
 	defer func() { _ = rows.Close() }()
 	err = objs.SQLScan(q.opts, rows)
+	if err == nil && q.cursor != nil && q.cursor.before {
+		reverseSlice(objs)
+	}
 	if err == nil && len(q.preloads) > 0 {
-		err = q.doPreloads(objs)
+		err = q.doPreloads(objs, q.preloads)
+	}
+	if err == nil {
+		err = q.runHooks(q.currentTx(), hooksOf(objs, afterFind))
 	}
 	return err
 }
 
-// Insert ...
+// Iterate queries the rows of the table of obj and scans each row into obj
+// before calling fn, so that large results are processed in constant memory.
+// obj is reset to its zero value before each row.
+// Returning ErrStop from fn stops the iteration without error. Preloads are
+// not loaded.
+//
+// Note that inside a transaction, no other query can run until the iteration
+// finishes.
+func (q *queryImpl) Iterate(ctx context.Context, obj core.IIterate, fn func() error) error {
+	q.ctx = ctx
+	q.assertTable(obj)
+	query, args, err := q.build("SELECT", obj, obj.SQLSelect)
+	if err != nil {
+		return err
+	}
+	rows, err := q.reader().QueryContext(q.ctx, query, args...)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = rows.Close() }()
+
+	tx, hooks := q.currentTx(), hooksOf(obj, afterFind)
+	v := reflect.ValueOf(obj).Elem()
+	for rows.Next() {
+		v.Set(reflect.Zero(v.Type()))
+		if err = rows.Scan(obj.SQLScanArgs(q.opts)...); err != nil {
+			return err
+		}
+		if err = q.runHooks(tx, hooks); err != nil {
+			return err
+		}
+		if err = fn(); err != nil {
+			if err == ErrStop {
+				return nil
+			}
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+// Insert inserts the given objects. Multiple objects, or objects with insert
+// hooks, are inserted inside a transaction. A slice is split into multiple
+// statements when its parameters exceed the limit of the database, see
+// MaxParams.
 func (q *queryImpl) Insert(objs ...core.IInsert) (int64, error) {
-	switch len(objs) {
-	case 0:
+	var items []interface{}
+	for _, obj := range objs {
+		items = append(items, q.chunksOf(obj)...)
+	}
+	switch {
+	case len(items) == 0:
 		return 0, nil
-	case 1:
-		if err := execBeforeInsert(objs[0]); err != nil {
-			return 0, err
+	case len(items) == 1 && !hasHooks(items[0], beforeInsert, afterInsert):
+		return q.insert(items[0].(core.IInsert))
+	}
+	return q.insertInTx(items, func(nq *queryImpl, obj interface{}) (int64, error) {
+		return nq.insert(obj.(core.IInsert))
+	})
+}
+
+// Upsert inserts the given objects, or handles the rows which conflict with
+// them on OnConflict columns (the primary key by default). The conflicting
+// rows are updated with all columns of the objects, unless DoNothing or
+// DoUpdate is given. The insert hooks are called and slices are split as with
+// Insert.
+//
+// Note that MySQL counts an updated row as 2 affected rows.
+func (q *queryImpl) Upsert(objs ...core.IUpsert) (int64, error) {
+	var items []interface{}
+	for _, obj := range objs {
+		items = append(items, q.chunksOf(obj)...)
+	}
+	switch {
+	case len(items) == 0:
+		return 0, nil
+	case len(items) == 1 && !hasHooks(items[0], beforeInsert, afterInsert):
+		return q.upsertObj(items[0].(core.IUpsert))
+	}
+	return q.insertInTx(items, func(nq *queryImpl, obj interface{}) (int64, error) {
+		return nq.upsertObj(obj.(core.IUpsert))
+	})
+}
+
+// chunksOf splits obj, when it is a slice, into slices of which the number of
+// parameters stays within the limit of the database.
+func (q *queryImpl) chunksOf(obj interface{}) []interface{} {
+	cols, ok := obj.(core.IColumns)
+	v := reflect.ValueOf(obj)
+	if !ok || v.Kind() != reflect.Slice || q.maxParams <= 0 {
+		return []interface{}{obj}
+	}
+	size := q.maxParams / len(cols.SQLColumns())
+	if size == 0 {
+		size = 1
+	}
+	n := v.Len()
+	if n <= size {
+		return []interface{}{obj}
+	}
+	chunks := make([]interface{}, 0, (n+size-1)/size)
+	for i := 0; i < n; i += size {
+		j := i + size
+		if j > n {
+			j = n
 		}
-		query, args, err := q.BuildInsert(objs[0])
-		if err != nil {
-			return 0, err
+		chunks = append(chunks, v.Slice(i, j).Interface())
+	}
+	return chunks
+}
+
+// insertInTx calls fn with each object inside a transaction, together with the
+// insert hooks of the object.
+func (q *queryImpl) insertInTx(objs []interface{}, fn func(*queryImpl, interface{}) (int64, error)) (int64, error) {
+	return q.inTx(func(tx Tx) (int64, error) {
+		nq := q.withTx(tx)
+		var count int64
+		for _, obj := range objs {
+			if err := q.runHooks(tx, hooksOf(obj, beforeInsert)); err != nil {
+				return 0, err
+			}
+			c, err := fn(nq, obj)
+			if err != nil {
+				return 0, err
+			}
+			if err = q.runHooks(tx, hooksOf(obj, afterInsert)); err != nil {
+				return 0, err
+			}
+			count += c
+		}
+		return count, nil
+	})
+}
+
+func (q *queryImpl) insert(obj core.IInsert) (int64, error) {
+	query, args, err := q.BuildInsert(obj)
+	if err != nil {
+		return 0, err
+	}
+	if !q.returning && !q.supportsReturning() {
+		return q.execInsert(obj, query, args)
+	}
+	return q.exec(obj, query, args)
+}
+
+// execInsert executes an insert without RETURNING. The id generated by the
+// database is written back to obj when a single object is inserted.
+func (q *queryImpl) execInsert(obj interface{}, query string, args []interface{}) (int64, error) {
+	res, err := q.db.ExecContext(q.ctx, query, args...)
+	if err != nil {
+		return 0, err
+	}
+	if items := itemsOf(obj); len(items) == 1 {
+		setLastInsertID(items[0], res)
+	}
+	return res.RowsAffected()
+}
+
+func setLastInsertID(obj interface{}, res sql.Result) {
+	if setter, ok := obj.(core.ISetLastInsertID); ok {
+		if id, err := res.LastInsertId(); err == nil && id != 0 {
+			setter.SQLSetLastInsertID(id)
 		}
+	}
+}
+
+func (q *queryImpl) upsertObj(obj core.IUpsert) (int64, error) {
+	query, args, err := q.BuildUpsert(obj)
+	if err != nil {
+		return 0, err
+	}
+	// Conflicting rows are not returned with DoNothing, and the order of the
+	// returned rows is not guaranteed, therefore they are matched by key.
+	if items := itemsOf(obj); q.returning && q.supportsReturning() &&
+		(q.upsert.doNothing || len(items) > 1) {
+		return q.execReturningByKey(items, query, args)
+	}
+	return q.exec(obj, query, args)
+}
+
+// exec executes a statement built for obj. In returning mode, the returned
+// columns are scanned back into obj, or into its items when obj is a slice.
+func (q *queryImpl) exec(obj interface{}, query string, args []interface{}) (int64, error) {
+	if !q.returning {
 		res, err := q.db.ExecContext(q.ctx, query, args...)
 		if err != nil {
 			return 0, err
 		}
 		return res.RowsAffected()
-	default:
-		doInsert := func(tx Tx) (int64, error) {
-			for _, obj := range objs {
-				if err := execBeforeInsert(obj); err != nil {
-					return 0, err
-				}
-			}
-			var count int64
-			for _, obj := range objs {
-				c, err := tx.Insert(obj)
-				if err != nil {
-					return 0, err
-				}
-				count += c
+	}
+	items := itemsOf(obj)
+	for _, item := range items {
+		if _, ok := item.(core.IScanArgs); !ok {
+			return 0, core.Errorf("sqlgen: %T can not scan returned columns", item)
+		}
+	}
+	if !q.supportsReturning() {
+		return q.execReload(items, query, args)
+	}
+
+	rows, err := q.db.QueryContext(q.ctx, query, args...)
+	if err != nil {
+		return 0, err
+	}
+	defer func() { _ = rows.Close() }()
+	var n int
+	for ; rows.Next(); n++ {
+		if n >= len(items) {
+			return int64(n), core.Errorf("sqlgen: more rows returned than objects")
+		}
+		if err = rows.Scan(items[n].(core.IScanArgs).SQLScanArgs(q.opts)...); err != nil {
+			return int64(n), err
+		}
+	}
+	return int64(n), rows.Err()
+}
+
+// execReturningByKey executes a statement with RETURNING and scans each
+// returned row into the item of the same primary key.
+func (q *queryImpl) execReturningByKey(items []interface{}, query string, args []interface{}) (int64, error) {
+	mapItem := make(map[string]interface{}, len(items))
+	for _, item := range items {
+		if _, ok := item.(core.IScanArgs); !ok {
+			return 0, core.Errorf("sqlgen: %T can not scan returned columns", item)
+		}
+		key, err := q.keyOf(item)
+		if err != nil {
+			return 0, err
+		}
+		mapItem[key] = item
+	}
+
+	rows, err := q.db.QueryContext(q.ctx, query, args...)
+	if err != nil {
+		return 0, err
+	}
+	defer func() { _ = rows.Close() }()
+	var n int
+	for ; rows.Next(); n++ {
+		v := reflect.New(reflect.TypeOf(items[0]).Elem())
+		row := v.Interface()
+		if err = rows.Scan(row.(core.IScanArgs).SQLScanArgs(q.opts)...); err != nil {
+			return int64(n), err
+		}
+		key, err := q.keyOf(row)
+		if err != nil {
+			return int64(n), err
+		}
+		item := mapItem[key]
+		if item == nil {
+			return int64(n), core.Errorf("sqlgen: returned row does not match any object")
+		}
+		reflect.ValueOf(item).Elem().Set(v.Elem())
+	}
+	return int64(n), rows.Err()
+}
+
+// keyOf returns the primary key of obj for matching returned rows.
+func (q *queryImpl) keyOf(obj interface{}) (string, error) {
+	pk, ok := obj.(core.IPrimaryKey)
+	if !ok {
+		return "", core.Errorf("sqlgen: %T must have primary key to return columns of multiple rows or DoNothing", obj)
+	}
+	w := NewDialectWriter(q.opts, 64)
+	if err := pk.SQLPrimaryKey(w); err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%#v", w.args), nil
+}
+
+// execReload emulates RETURNING for dialects without it. The id generated by
+// the database is written back to the object, which is then selected again by
+// its primary key. The row is reloaded even when no row is affected, because
+// MySQL does not count the rows updated with unchanged values, except for
+// DoNothing which leaves the object as is on conflict.
+func (q *queryImpl) execReload(items []interface{}, query string, args []interface{}) (int64, error) {
+	if len(items) != 1 {
+		return 0, core.Errorf("sqlgen: can not return columns of multiple rows without RETURNING")
+	}
+	get, ok := items[0].(core.IGet)
+	pk, okPK := items[0].(core.IPrimaryKey)
+	if !ok || !okPK {
+		return 0, core.Errorf("sqlgen: %T must have primary key to return columns without RETURNING", items[0])
+	}
+
+	res, err := q.db.ExecContext(q.ctx, query, args...)
+	if err != nil {
+		return 0, err
+	}
+	n, err := res.RowsAffected()
+	if err != nil || n == 0 && q.upsert.doNothing {
+		return n, err
+	}
+	setLastInsertID(items[0], res)
+	query, args, err = q.NewQuery().Where(WriterToFunc(pk.SQLPrimaryKey)).BuildGet(get)
+	if err != nil {
+		return n, err
+	}
+	row := q.db.QueryRowContext(q.ctx, query, args...)
+	sqlErr := get.SQLScan(q.opts, row.Row)
+	if err = row.Log(sqlErr); sqlErr == sql.ErrNoRows {
+		return n, nil
+	}
+	return n, err
+}
+
+// supportsReturning reports whether the database supports RETURNING.
+func (q *queryImpl) supportsReturning() bool {
+	return q.opts.Dialect.Returning()
+}
+
+// withReturning returns a copy of the query which returns all columns of obj
+// in returning mode.
+func (q *queryImpl) withReturning(obj interface{}) *queryImpl {
+	cols, ok := obj.(core.IColumns)
+	if !ok || !q.returning || !q.supportsReturning() {
+		return q
+	}
+	nq := q.cloneWithPreds(nil)
+	nq.suffixes = append(nq.suffixes, WriterToFunc(func(w core.SQLWriter) error {
+		w.WriteRawString("RETURNING ")
+		for i, col := range cols.SQLColumns() {
+			if i != 0 {
+				w.WriteByte(',')
 			}
-			return count, nil
+			w.WriteName(col)
 		}
+		return nil
+	}))
+	return nq
+}
 
-		switch x := q.db.(type) {
-		case Tx:
-			return doInsert(x)
-		case *Database:
-			tx, err := x.Begin()
-			if err != nil {
+// inTx calls fn with a new transaction, or with a savepoint of the current
+// transaction, which is committed after fn returns without error. The
+// savepoint rolls back the statements of fn when a hook fails, while the
+// current transaction goes on.
+func (q *queryImpl) inTx(fn func(Tx) (int64, error)) (int64, error) {
+	var tx Tx
+	var err error
+	switch x := q.db.(type) {
+	case Tx:
+		tx, err = x.BeginContext(q.ctx)
+	case *Database:
+		tx, err = x.BeginContext(q.ctx)
+	default:
+		panic("Expect Database or Tx")
+	}
+	if err != nil {
+		return 0, err
+	}
+	defer func() { _ = tx.Rollback() }()
+	n, err := fn(tx)
+	if err != nil {
+		return 0, err
+	}
+	return n, tx.Commit()
+}
+
+// withTx returns a copy of the query which runs in the given transaction.
+func (q *queryImpl) withTx(tx Tx) *queryImpl {
+	nq := q.cloneWithPreds(nil)
+	nq.db = tx.(dbInterface)
+	return nq
+}
+
+// currentTx returns the transaction which the query runs in, or nil.
+func (q *queryImpl) currentTx() Tx {
+	tx, _ := q.db.(Tx)
+	return tx
+}
+
+// Update updates the given objects. With more than one object, each object is
+// updated by its primary key inside a transaction, and the total number of
+// affected rows is returned. See UpdateValues for updating them with a single
+// statement. Objects with update hooks are also updated inside a transaction.
+func (q *queryImpl) Update(objs ...core.IUpdate) (int64, error) {
+	switch {
+	case len(objs) == 0:
+		return 0, nil
+	case len(objs) == 1 && !hasHooks(objs[0], beforeUpdate, afterUpdate):
+		return q.update(objs[0])
+	}
+	return q.inTx(func(tx Tx) (int64, error) {
+		for _, obj := range objs {
+			if err := q.runHooks(tx, hooksOf(obj, beforeUpdate)); err != nil {
 				return 0, err
 			}
-			defer func() { _ = tx.Rollback() }()
-			n, err := doInsert(tx)
-			if err != nil {
+		}
+		count, err := q.withTx(tx).updateObjects(objs)
+		if err != nil {
+			return 0, err
+		}
+		for _, obj := range objs {
+			if err = q.runHooks(tx, hooksOf(obj, afterUpdate)); err != nil {
 				return 0, err
 			}
-			return n, tx.Commit()
-		default:
-			panic("Expect Database or Tx")
 		}
+		return count, nil
+	})
+}
+
+func (q *queryImpl) update(obj core.IUpdate) (int64, error) {
+	query, args, err := q.BuildUpdate(obj)
+	if err != nil {
+		return 0, err
 	}
+	return q.exec(obj, query, args)
 }
 
-// Update ...
-func (q *queryImpl) Update(objs ...core.IUpdate) (int64, error) {
-	switch len(objs) {
-	case 1:
-		query, args, err := q.BuildUpdate(objs[0])
+func (q *queryImpl) updateObjects(objs []core.IUpdate) (int64, error) {
+	switch {
+	case len(objs) == 1:
+		return q.update(objs[0])
+	case q.updateVal && q.opts.Dialect.UpdateFrom():
+		return q.updateValues(objs)
+	}
+	var count int64
+	for _, obj := range objs {
+		pk, ok := obj.(core.IPrimaryKey)
+		if !ok {
+			return 0, core.Errorf("sqlgen: %v must have primary key to be updated with other objects", obj.SQLTableName())
+		}
+		c, err := q.cloneWithPreds([]interface{}{WriterToFunc(pk.SQLPrimaryKey)}).update(obj)
+		if err != nil {
+			return 0, err
+		}
+		count += c
+	}
+	return count, nil
+}
+
+// updateValues updates objs with UPDATE ... FROM (VALUES ...) statements,
+// splitting them so that each statement stays within the parameter limit.
+func (q *queryImpl) updateValues(objs []core.IUpdate) (int64, error) {
+	if len(q.whereParts) != 0 {
+		return 0, core.Errorf("sqlgen: UPDATE ... FROM (VALUES ...) must not have WHERE")
+	}
+	vals := make([]core.IUpdateValues, len(objs))
+	for i, obj := range objs {
+		v, ok := obj.(core.IUpdateValues)
+		if !ok {
+			return 0, core.Errorf("sqlgen: %v does not support UPDATE ... FROM (VALUES ...)", obj.SQLTableName())
+		}
+		if obj.SQLTableName() != objs[0].SQLTableName() {
+			return 0, core.Errorf("sqlgen: can not update %v and %v together", objs[0].SQLTableName(), obj.SQLTableName())
+		}
+		vals[i] = v
+	}
+
+	size := len(vals)
+	if q.maxParams > 0 {
+		size = q.maxParams / len(vals[0].SQLColumns())
+		if size == 0 {
+			size = 1
+		}
+	}
+	var count int64
+	for len(vals) > 0 {
+		n := len(vals)
+		if n > size {
+			n = size
+		}
+		query, args, err := q.BuildUpdateValues(vals[:n])
 		if err != nil {
 			return 0, err
 		}
@@ -492,11 +1081,92 @@ func (q *queryImpl) Update(objs ...core.IUpdate) (int64, error) {
 		if err != nil {
 			return 0, err
 		}
-		return res.RowsAffected()
-	case 0:
-		return 0, nil
+		c, err := res.RowsAffected()
+		if err != nil {
+			return 0, err
+		}
+		count += c
+		vals = vals[n:]
 	}
-	return 0, errors.New("TODO")
+	return count, nil
+}
+
+// BuildUpdateValues builds a single statement which updates all columns of the
+// given objects by their primary keys. The values are typed by a SELECT from
+// the table itself:
+//
+//	UPDATE "t" AS "_t" SET "name" = "_v"."name"
+//	FROM (SELECT "id","name" FROM "t" WHERE false UNION ALL VALUES ($1,$2),($3,$4)) AS "_v"
+//	WHERE "_t"."id" = "_v"."id"
+func (q *queryImpl) BuildUpdateValues(objs []core.IUpdateValues) (string, []interface{}, error) {
+	if len(objs) == 0 {
+		return "", nil, core.Errorf("sqlgen: UPDATE ... FROM (VALUES ...) requires at least one object")
+	}
+	if q.returning {
+		return "", nil, core.Errorf("sqlgen: UPDATE ... FROM (VALUES ...) does not support Returning")
+	}
+	q.assertTable(objs[0])
+	obj := objs[0]
+	schemaName, tableName := schemaNameOf(obj), obj.SQLTableName()
+	cols, pks := obj.SQLColumns(), obj.SQLPrimaryKeyColumns()
+	return q.build("UPDATE VALUES", nil, func(w core.SQLWriter) error {
+		w.WriteRawString("UPDATE ")
+		w.WritePrefixedName(schemaName, tableName)
+		w.WriteRawString(" AS ")
+		w.WriteName("_t")
+		w.WriteRawString(" SET ")
+		flag := false
+		for _, col := range cols {
+			if containsString(pks, col) {
+				continue
+			}
+			flag = true
+			w.WriteName(col)
+			w.WriteRawString(" = ")
+			w.WriteName("_v")
+			w.WriteByte('.')
+			w.WriteName(col)
+			w.WriteByte(',')
+		}
+		if !flag {
+			return core.ErrNoColumn
+		}
+		w.TrimLast(1)
+
+		w.WriteRawString(" FROM (SELECT ")
+		for _, col := range cols {
+			w.WriteName(col)
+			w.WriteByte(',')
+		}
+		w.TrimLast(1)
+		w.WriteRawString(" FROM ")
+		w.WritePrefixedName(schemaName, tableName)
+		w.WriteRawString(" WHERE false UNION ALL VALUES ")
+		for _, obj := range objs {
+			w.WriteByte('(')
+			w.WriteMarkers(len(cols))
+			w.WriteRawString("),")
+			w.WriteArgs(obj.SQLArgs(w.Opts(), false))
+		}
+		w.TrimLast(1)
+		w.WriteRawString(") AS ")
+		w.WriteName("_v")
+
+		w.WriteRawString(" WHERE ")
+		for i, pk := range pks {
+			if i != 0 {
+				w.WriteRawString(" AND ")
+			}
+			w.WriteName("_t")
+			w.WriteByte('.')
+			w.WriteName(pk)
+			w.WriteRawString(" = ")
+			w.WriteName("_v")
+			w.WriteByte('.')
+			w.WriteName(pk)
+		}
+		return nil
+	})
 }
 
 // UpdateMap ...
@@ -504,8 +1174,25 @@ func (q *queryImpl) UpdateMap(m map[string]interface{}) (int64, error) {
 	return q.Update(core.Map{Table: q.table, M: m})
 }
 
-// Delete ...
+// Delete deletes the given object. Objects with delete hooks are deleted
+// inside a transaction.
 func (q *queryImpl) Delete(obj core.ITableName) (int64, error) {
+	if !hasHooks(obj, beforeDelete, afterDelete) {
+		return q.delete(obj)
+	}
+	return q.inTx(func(tx Tx) (int64, error) {
+		if err := q.runHooks(tx, hooksOf(obj, beforeDelete)); err != nil {
+			return 0, err
+		}
+		n, err := q.withTx(tx).delete(obj)
+		if err != nil {
+			return 0, err
+		}
+		return n, q.runHooks(tx, hooksOf(obj, afterDelete))
+	})
+}
+
+func (q *queryImpl) delete(obj core.ITableName) (int64, error) {
 	query, args, err := q.BuildDelete(obj)
 	if err != nil {
 		return 0, err
@@ -523,7 +1210,7 @@ func (q *queryImpl) Count(obj core.ITableName, preds ...interface{}) (n uint64,
 	if err != nil {
 		return 0, err
 	}
-	err = q.db.QueryRowContext(q.ctx, query, args...).Scan(&n)
+	err = q.reader().QueryRowContext(q.ctx, query, args...).Scan(&n)
 	return
 }
 
@@ -587,6 +1274,27 @@ func (q *queryImpl) Offset(offset uint64) Query {
 	return q
 }
 
+// After requests the rows following the given cursor in the order of the ORDER
+// BY columns, which is faster than Offset on large tables. The ORDER BY columns
+// must identify a row uniquely, e.g. by ending with the primary key, and have
+// the same direction. An empty cursor requests the first page. See Cursor.
+//
+//	q := db.OrderBy("created_at", "id").Limit(20).After(cursor)
+//	err := q.Find(&users)
+//	next, err := q.Cursor(users[len(users)-1])
+func (q *queryImpl) After(cursor string) Query {
+	q.cursor = &cursorPart{value: cursor}
+	return q
+}
+
+// Before requests the rows preceding the given cursor. Find still returns the
+// rows in the order of the ORDER BY columns, while Iterate visits them in
+// reversed order. An empty cursor requests the last page. See After.
+func (q *queryImpl) Before(cursor string) Query {
+	q.cursor = &cursorPart{value: cursor, before: true}
+	return q
+}
+
 // Suffix adds an expression to the end of the query
 func (q *queryImpl) Suffix(sql string, args ...interface{}) Query {
 	q.suffixes = append(q.suffixes, ExprString{sql, args})
@@ -599,6 +1307,59 @@ func (q *queryImpl) UpdateAll() Query {
 	return q
 }
 
+// UpdateValues makes Update with multiple objects issue a single
+// UPDATE ... FROM (VALUES ...) statement on Postgres instead of one statement
+// per object, which is faster for large batches. All columns are updated as
+// with UpdateAll, and the query must not have WHERE or Returning.
+func (q *queryImpl) UpdateValues() Query {
+	q.updateVal = true
+	return q
+}
+
+// OnConflict sets the conflict columns of Upsert. The default is the primary
+// key.
+func (q *queryImpl) OnConflict(cols ...string) Query {
+	q.upsert.conflictCols = cols
+	return q
+}
+
+// DoNothing makes Upsert leave the conflicting rows unchanged.
+func (q *queryImpl) DoNothing() Query {
+	q.upsert.doNothing = true
+	return q
+}
+
+// DoUpdate makes Upsert update only the given columns of the conflicting rows.
+func (q *queryImpl) DoUpdate(cols ...string) Query {
+	q.upsert.updateCols = cols
+	return q
+}
+
+// SkipCreated makes Upsert keep the created time columns of the conflicting
+// rows.
+func (q *queryImpl) SkipCreated() Query {
+	q.upsert.skipCreated = true
+	return q
+}
+
+// Returning makes Insert, Upsert and Update scan the columns of the written
+// rows back into the objects, including the values generated by the database.
+// Without RETURNING, the id from LastInsertId is written back and the object
+// is selected again by its primary key. The rows returned by an upsert of
+// multiple objects or with DoNothing are matched to the objects by primary key.
+func (q *queryImpl) Returning() Query {
+	q.returning = true
+	return q
+}
+
+// UsePrimary makes the query read from the primary instead of a replica, e.g.
+// to read the rows just written, which may not have reached the replicas yet.
+// Queries inside a transaction always use the primary.
+func (q *queryImpl) UsePrimary() Query {
+	q.usePrimary = true
+	return q
+}
+
 func (q *queryImpl) In(column string, args ...interface{}) Query {
 	q.whereParts = append(q.whereParts, NewInPart(true, column, args...))
 	return q
@@ -627,17 +1388,30 @@ func (q *queryImpl) Apply(funcs ...func(query CommonQuery)) Query {
 	return q
 }
 
+// Preload loads the related rows of the given table after Get or Find. Nested
+// tables are separated by dots, e.g. "order.order_line.product", and each level
+// is loaded with a single query. The predicates apply to the last table of the
+// path. A func(CommonQuery) predicate customizes the query of that level, e.g.
+// for ordering and limit:
+//
+//	db.Preload("order", "status = ?", "active").
+//	    Preload("order.order_line", func(q sq.CommonQuery) {
+//	        q.OrderBy("created_at DESC").Limit(100)
+//	    }).
+//	    Find(&users)
+//
+// Note that the limit is applied to the query of the whole level, not to each
+// parent row.
 func (q *queryImpl) Preload(table string, preds ...interface{}) Query {
-	part := preloadPart{table, preds}
-	q.preloads = append(q.preloads, part)
-	return q
-}
-
-func execBeforeInsert(obj interface{}) error {
-	if in, ok := obj.(BeforeInsertInterface); ok {
-		return in.BeforeInsert()
+	part := q.preloads.add(table)
+	for _, pred := range preds {
+		if fn, ok := pred.(func(CommonQuery)); ok {
+			part.funcs = append(part.funcs, fn)
+		} else {
+			part.preds = append(part.preds, pred)
+		}
 	}
-	return nil
+	return q
 }
 
 func (q *queryImpl) AddError(err error) {
@@ -0,0 +1,111 @@
+package sq
+
+import (
+	"context"
+	"database/sql"
+	"strconv"
+	"strings"
+	"sync/atomic"
+)
+
+// NodePrimary is the node of LogEntry for queries served by the primary.
+// Replicas are named "replica-1", "replica-2", etc. in the order they are
+// given.
+const NodePrimary = "primary"
+
+// Replicas opens read replicas with the driver of Connect. Reads of queries
+// outside of transactions are served by the replicas, see Query.UsePrimary.
+func Replicas(connStrs ...string) Option {
+	return OptionFunc(func(db *Database) {
+		db.replicaConnStrs = append(db.replicaConnStrs, connStrs...)
+	})
+}
+
+// ReplicaDBs adds already opened databases as read replicas, see Replicas.
+func ReplicaDBs(dbs ...*sql.DB) Option {
+	return OptionFunc(func(db *Database) {
+		for _, r := range dbs {
+			db.addReplica(r)
+		}
+	})
+}
+
+// Balancer selects the replica for each read.
+type Balancer int
+
+// Balancers
+const (
+	RoundRobin Balancer = iota
+	LeastConn
+)
+
+// SQLOption ...
+func (b Balancer) SQLOption(db *Database) {
+	db.balancer = b
+}
+
+// replica is a read replica of a Database. It only serves the reads of
+// queries, while the other methods go to the primary.
+type replica struct {
+	*Database
+	node string
+	conn *sql.DB
+}
+
+func (r *replica) QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	return r.Database.queryContext(ctx, r.node, r.conn, query, args)
+}
+
+func (r *replica) QueryRowContext(ctx context.Context, query string, args ...interface{}) Row {
+	return r.Database.queryRowContext(ctx, r.node, r.conn, query, args)
+}
+
+func (db *Database) addReplica(conn *sql.DB) {
+	node := "replica-" + strconv.Itoa(len(db.replicas)+1)
+	db.replicas = append(db.replicas, &replica{Database: db, node: node, conn: conn})
+}
+
+// reader returns the replica for the next read, or the primary without
+// replicas.
+func (db *Database) reader() dbInterface {
+	switch {
+	case len(db.replicas) == 0:
+		return db
+	case db.balancer == LeastConn:
+		best := db.replicas[0]
+		inUse := best.conn.Stats().InUse
+		for _, r := range db.replicas[1:] {
+			if n := r.conn.Stats().InUse; n < inUse {
+				best, inUse = r, n
+			}
+		}
+		return best
+	default:
+		i := atomic.AddUint32(&db.next, 1) - 1
+		return db.replicas[i%uint32(len(db.replicas))]
+	}
+}
+
+// reader returns where the query reads from. It is a replica unless the query
+// runs inside a transaction or UsePrimary is set.
+func (q *queryImpl) reader() dbInterface {
+	db, ok := q.db.(*Database)
+	if !ok || q.usePrimary {
+		return q.db
+	}
+	return db.reader()
+}
+
+// readerFor returns the reader for a query built from raw SQL, which only goes
+// to a replica when it is a SELECT.
+func (q *queryImpl) readerFor(query string) dbInterface {
+	if !isSelect(query) {
+		return q.db
+	}
+	return q.reader()
+}
+
+func isSelect(query string) bool {
+	query = strings.TrimLeft(query, " \t\r\n(")
+	return len(query) >= 6 && strings.EqualFold(query[:6], "SELECT")
+}
@@ -1,7 +1,6 @@
 package sq
 
 import (
-	"strconv"
 	"unsafe"
 
 	"github.com/ng-vu/sqlgen/core"
@@ -12,22 +11,32 @@ var _ core.SQLWriter = &Writer{}
 type Writer struct {
 	c int64
 
-	opts   core.Opts
-	quote  byte
-	marker byte
+	opts    core.Opts
+	dialect core.Dialect
+	quote   byte
 
 	buf  []byte
 	args []interface{}
 	scan []interface{}
 }
 
+// NewWriter returns a writer with the given quote and marker, which override
+// those of the dialect of opts.
+//
+// Deprecated: Use NewDialectWriter.
 func NewWriter(opts core.Opts, quote byte, marker byte, size int) *Writer {
+	opts.Dialect = withQuoteAndMarker(opts.Dialect, quote, marker)
+	return NewDialectWriter(opts, size)
+}
+
+// NewDialectWriter returns a writer for the dialect of opts.
+func NewDialectWriter(opts core.Opts, size int) *Writer {
 	return &Writer{
-		quote:  quote,
-		marker: marker,
-		opts:   opts,
-		buf:    make([]byte, 0, size),
-		args:   make([]interface{}, 0, 64),
+		opts:    opts,
+		dialect: opts.Dialect,
+		quote:   opts.Dialect.Quote(),
+		buf:     make([]byte, 0, size),
+		args:    make([]interface{}, 0, 64),
 	}
 }
 
@@ -47,10 +56,19 @@ func (w *Writer) Len() int {
 }
 
 func (w *Writer) WriteArg(arg interface{}) {
+	if c, ok := w.dialect.(argConverter); ok {
+		arg = c.convertArg(arg)
+	}
 	w.args = append(w.args, arg)
 }
 
 func (w *Writer) WriteArgs(args []interface{}) {
+	if c, ok := w.dialect.(argConverter); ok {
+		for _, arg := range args {
+			w.args = append(w.args, c.convertArg(arg))
+		}
+		return
+	}
 	w.args = append(w.args, args...)
 }
 
@@ -63,31 +81,24 @@ func (w *Writer) WriteScanArgs(args []interface{}) {
 }
 
 func (w *Writer) WriteMarker() {
-	if w.marker == '?' {
-		w.buf = appendQuestionMarker(w.buf)
-	} else {
-		w.buf = appendDollarMarker(w.buf, &w.c)
-	}
+	w.c++
+	w.buf = w.dialect.AppendMarker(w.buf, w.c)
 }
 
 func (w *Writer) WriteMarkers(n int) {
-	if w.marker == '?' {
-		w.buf = appendQuestionMarkers(w.buf, n)
-	} else {
-		w.buf = appendDollarMarkers(w.buf, &w.c, n)
-	}
+	w.buf = appendMarkers(w.buf, &w.c, w.dialect, n)
 }
 
 func (w *Writer) WriteQuery(query []byte) {
-	w.buf = appendAndReplace(w.buf, &w.c, w.quote, w.marker, unsafeBytesToString(query), "")
+	w.buf = appendAndReplace(w.buf, &w.c, w.dialect, unsafeBytesToString(query), "")
 }
 
 func (w *Writer) WriteQueryString(query string) {
-	w.buf = appendAndReplace(w.buf, &w.c, w.quote, w.marker, query, "")
+	w.buf = appendAndReplace(w.buf, &w.c, w.dialect, query, "")
 }
 
 func (w *Writer) WriteQueryStringWithPrefix(prefix, query string) {
-	w.buf = appendAndReplace(w.buf, &w.c, w.quote, w.marker, query, prefix)
+	w.buf = appendAndReplace(w.buf, &w.c, w.dialect, query, prefix)
 }
 
 func (w *Writer) WritePrefixedName(schema, name string) {
@@ -106,6 +117,38 @@ func (w *Writer) WriteName(name string) {
 	w.buf = append(w.buf, w.quote)
 }
 
+// WriteOnConflict writes the conflict clause of an upsert, see
+// core.Dialect.
+func (w *Writer) WriteOnConflict(conflictCols []string, updateCols []string) {
+	w.dialect.WriteOnConflict(w, conflictCols, updateCols)
+}
+
+// WriteSetColumns writes the SET list of an UPDATE with a marker for each
+// column, as a tuple when the dialect supports it.
+func (w *Writer) WriteSetColumns(cols []string) {
+	if len(cols) > 1 && w.dialect.TupleUpdate() {
+		w.WriteByte('(')
+		for i, col := range cols {
+			if i != 0 {
+				w.WriteByte(',')
+			}
+			w.WriteName(col)
+		}
+		w.WriteRawString(") = (")
+		w.WriteMarkers(len(cols))
+		w.WriteByte(')')
+		return
+	}
+	for i, col := range cols {
+		if i != 0 {
+			w.WriteByte(',')
+		}
+		w.WriteName(col)
+		w.WriteRawString(" = ")
+		w.WriteMarker()
+	}
+}
+
 func (w *Writer) WriteQueryName(name string) {
 	if shouldQuote(name) {
 		w.buf = append(w.buf, w.quote)
@@ -140,66 +183,13 @@ func (w *Writer) ScanArgs() []interface{} {
 	return w.scan
 }
 
-func appendQuestionMarker(b []byte) []byte {
-	b = append(b, '?')
-	return b
-}
-
-func appendQuestionMarkers(b []byte, n int) []byte {
-	b = append(b, '?')
-	for i := 1; i < n; i++ {
-		b = append(b, ",?"...)
-	}
-	return b
-}
-
-func appendDollarMarker(b []byte, c *int64) []byte {
-	*c++
-	b = append(b, '$')
-	b = strconv.AppendInt(b, *c, 10)
-	return b
-}
-
-func appendDollarMarkers(b []byte, c *int64, n int) []byte {
-	*c++
-	b = append(b, '$')
-	b = strconv.AppendInt(b, *c, 10)
-	for i := 1; i < n; i++ {
-		*c++
-		b = append(b, ",$"...)
-		b = strconv.AppendInt(b, *c, 10)
-	}
-	return b
-}
-
-func appendAndReplace(b []byte, c *int64, quote, marker byte, query string, schema string) []byte {
-	last := byte(0)
-	idx := 0
-	for i := 0; i < len(query); i++ {
-		ch := query[i]
-		switch {
-		case ch == '"' && ch != quote:
-			b = append(b, query[idx:i]...)
-			b = append(b, quote)
-			idx = i + 1
-		case ch == '?' && ch != marker:
-			*c++
-			b = append(b, query[idx:i]...)
-			b = append(b, marker)
-			b = strconv.AppendInt(b, *c, 10)
-			idx = i + 1
-		case ch == '.' && last == '$':
-			b = append(b, query[idx:i-1]...)
-			if schema != "" {
-				b = append(b, schema...)
-				b = append(b, '.')
-			}
-			idx = i + 1
+func appendMarkers(b []byte, c *int64, dialect core.Dialect, n int) []byte {
+	for i := 0; i < n; i++ {
+		if i != 0 {
+			b = append(b, ',')
 		}
-		last = ch
-	}
-	if idx < len(query) {
-		b = append(b, query[idx:]...)
+		*c++
+		b = dialect.AppendMarker(b, *c)
 	}
 	return b
 }
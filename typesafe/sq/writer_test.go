@@ -1,6 +1,12 @@
 package sq
 
-import "testing"
+import (
+	"database/sql/driver"
+	"reflect"
+	"testing"
+
+	"github.com/ng-vu/sqlgen/core"
+)
 
 func TestAppendAndReplace(t *testing.T) {
 	tests := []struct {
@@ -38,12 +44,58 @@ func TestAppendAndReplace(t *testing.T) {
 			"INSERT INTO schema.`user`(`id`, `name`) VALUES (?,?)",
 			`INSERT INTO schema."user"("id", "name") VALUES ($1,$2)`,
 		},
+		{
+			`note = 'why? "not"' AND id = ?`,
+			`note = 'why? "not"' AND id = ?`,
+			`note = 'why? "not"' AND id = $1`,
+		},
+		{
+			`note = 'it''s ?' AND id = ?`,
+			`note = 'it''s ?' AND id = ?`,
+			`note = 'it''s ?' AND id = $1`,
+		},
+		{
+			`note = E'\'?' AND id = ?`,
+			`note = E'\'?' AND id = ?`,
+			`note = E'\'?' AND id = $1`,
+		},
+		{
+			`"a?b" = ?`,
+			"`a?b` = ?",
+			`"a?b" = $1`,
+		},
+		{
+			`data ?| ? AND data ?& ? AND name || ? || ?||'x'`,
+			`data ?| ? AND data ?& ? AND name || ? || ?||'x'`,
+			`data ?| $1 AND data ?& $2 AND name || $3 || $4||'x'`,
+		},
+		{
+			"id = ? -- why?\nAND x = ? /* \"what?\" */",
+			"id = ? -- why?\nAND x = ? /* \"what?\" */",
+			"id = $1 -- why?\nAND x = $2 /* \"what?\" */",
+		},
+		{
+			`$$ SELECT ? $$, $fn$ "?" $fn$, ?`,
+			`$$ SELECT ? $$, $fn$ "?" $fn$, ?`,
+			`$$ SELECT ? $$, $fn$ "?" $fn$, $1`,
+		},
+		{
+			`id = $1 AND $.name = ?`,
+			`id = $1 AND schema.name = ?`,
+			`id = $1 AND schema.name = $1`,
+		},
+		{
+			`note = 'unterminated ?`,
+			`note = 'unterminated ?`,
+			`note = 'unterminated ?`,
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.input, func(t *testing.T) {
 			{
 				var b []byte
-				output := appendAndReplace(b, nil, '`', '?', tt.input, "schema")
+				var c int64
+				output := appendAndReplace(b, &c, MySQL, tt.input, "schema")
 				if string(output) != tt.exp1 {
 					t.Errorf("\nExpect: %s\nOutput: %s\n", tt.exp1, output)
 				}
@@ -51,7 +103,7 @@ func TestAppendAndReplace(t *testing.T) {
 			{
 				var b []byte
 				var c int64
-				output := appendAndReplace(b, &c, '"', '$', tt.input, "schema")
+				output := appendAndReplace(b, &c, Postgres, tt.input, "schema")
 				if string(output) != tt.exp2 {
 					t.Errorf("\nExpect: %s\nOutput: %s\n", tt.exp2, output)
 				}
@@ -59,3 +111,98 @@ func TestAppendAndReplace(t *testing.T) {
 		})
 	}
 }
+
+func TestWriteSetColumns(t *testing.T) {
+	tests := []struct {
+		dialect Dialect
+		cols    []string
+		exp     string
+	}{
+		{Postgres, []string{"a"}, `"a" = $1`},
+		{Postgres, []string{"a", "b"}, `("a","b") = ($1,$2)`},
+		{MySQL, []string{"a", "b"}, "`a` = ?,`b` = ?"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.exp, func(t *testing.T) {
+			w := NewDialectWriter(core.Opts{Dialect: tt.dialect}, 64)
+			w.WriteSetColumns(tt.cols)
+			if w.String() != tt.exp {
+				t.Errorf("\nExpect: %s\nOutput: %s\n", tt.exp, w.String())
+			}
+		})
+	}
+}
+
+func TestWriteLimit(t *testing.T) {
+	tests := []struct {
+		dialect Dialect
+		limit   string
+		offset  string
+		exp     string
+	}{
+		{Postgres, "10", "", `LIMIT 10 `},
+		{Postgres, "", "20", `OFFSET 20 `},
+		{MySQL, "10", "20", `LIMIT 10 OFFSET 20 `},
+		{MySQL, "", "20", `LIMIT 18446744073709551615 OFFSET 20 `},
+	}
+	for _, tt := range tests {
+		t.Run(tt.exp, func(t *testing.T) {
+			w := NewDialectWriter(core.Opts{Dialect: tt.dialect}, 64)
+			tt.dialect.WriteLimit(w, tt.limit, tt.offset)
+			if w.String() != tt.exp {
+				t.Errorf("\nExpect: %s\nOutput: %s\n", tt.exp, w.String())
+			}
+		})
+	}
+}
+
+func TestDeprecatedQuoteAndMarker(t *testing.T) {
+	writerOf := func(driver string, opts ...Option) *Writer {
+		db, err := Connect(driver, "", opts...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return NewDialectWriter(db.Opts(), 64)
+	}
+	tests := []struct {
+		w   *Writer
+		exp string
+	}{
+		{NewWriter(core.Opts{}, '"', '$', 64), `("a","b") = ($1,$2)`},
+		{NewWriter(core.Opts{}, '`', '?', 64), "`a` = ?,`b` = ?"},
+		{writerOf("postgres", QuestionMarker, OptionFunc(BacktickEscape)), "(`a`,`b`) = (?,?)"},
+		{writerOf("mysql", DollarMarker, OptionFunc(DoubleQuoteEscape)), `"a" = $1,"b" = $2`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.exp, func(t *testing.T) {
+			tt.w.WriteSetColumns([]string{"a", "b"})
+			if tt.w.String() != tt.exp {
+				t.Errorf("\nExpect: %s\nOutput: %s\n", tt.exp, tt.w.String())
+			}
+		})
+	}
+}
+
+func TestWriteArgJSON(t *testing.T) {
+	tests := []struct {
+		dialect Dialect
+		exp     interface{}
+	}{
+		{Postgres, []byte(`{"a":1}`)},
+		{MySQL, []byte(`{"a":1}`)},
+		{SQLite, `{"a":1}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.dialect.Name(), func(t *testing.T) {
+			w := NewDialectWriter(core.Opts{Dialect: tt.dialect}, 64)
+			w.WriteArg(core.JSON{V: map[string]int{"a": 1}})
+			value, err := w.args[0].(driver.Valuer).Value()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(value, tt.exp) {
+				t.Errorf("\nExpect: %#v\nOutput: %#v\n", tt.exp, value)
+			}
+		})
+	}
+}